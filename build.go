@@ -0,0 +1,240 @@
+package rbt
+
+import (
+    "errors"
+    "fmt"
+    "iter"
+    "math/bits"
+    "sort"
+)
+
+// ErrNotSorted is returned when entries expected in strictly ascending key
+// order are not.
+var ErrNotSorted = errors.New("rbt: keys are not in ascending order")
+
+// Replace tree contents with nodes, which must be in strictly ascending key
+// order, linking them into a balanced red-black tree in O(n). Insertion
+// order index is not updated.
+func (t *RbMap) build(nodes []*RbMapNode) {
+    // Middle split keeps all leaves within one level from each other, so
+    // coloring the bottom level red keeps black height equal on all paths.
+    t.root = linkSorted(nodes, nil, 0, bits.Len(uint(len(nodes)))-1)
+    if t.root != nil {
+        t.root.isred = false
+    }
+    t.size = len(nodes)
+}
+
+func linkSorted(nodes []*RbMapNode, parent *RbMapNode, depth, redDepth int) *RbMapNode {
+    if len(nodes) == 0 {
+        return nil
+    }
+    m := len(nodes) / 2
+    n := nodes[m]
+    n.parent, n.isred, n.size = parent, depth == redDepth, len(nodes)
+    n.left = linkSorted(nodes[:m], n, depth+1, redDepth)
+    n.right = linkSorted(nodes[m+1:], n, depth+1, redDepth)
+    return n
+}
+
+// ErrDuplicateKey is returned (wrapped, with the offending key) by
+// InsertManyUnique.
+var ErrDuplicateKey = errors.New("rbt: duplicate key")
+
+// Insert keys[i] with values[i] for all i, requiring all keys to be new:
+// if any key is already in the tree or occurs twice in keys, an error
+// wrapping ErrDuplicateKey and naming the key is returned, and nothing is
+// inserted. Returns number of inserted entries.
+func (t *RbMap) InsertManyUnique(keys, values []interface{}) (int, error) {
+    if len(keys) != len(values) {
+        return 0, fmt.Errorf("rbt: %d keys, but %d values", len(keys), len(values))
+    }
+    batch := NewRbMap(t.less)
+    for _, k := range keys {
+        if t.FindNode(k) != nil || !batch.Insert(k, nil) {
+            return 0, fmt.Errorf("%w: %v", ErrDuplicateKey, k)
+        }
+    }
+    for i, k := range keys {
+        t.Insert(k, values[i])
+    }
+    return len(keys), nil
+}
+
+// Create new RbMap with entries yielded by seq; later duplicates of a key
+// overwrite earlier ones. As long as keys come in strictly ascending order,
+// entries are collected and linked into a balanced tree in O(n) at once;
+// the first out-of-order key switches to plain inserts for the rest.
+func Collect(less LessFunc, seq iter.Seq2[interface{}, interface{}]) *RbMap {
+    t := NewRbMap(less)
+    var nodes []*RbMapNode
+    sorted := true
+    for k, v := range seq {
+        if sorted {
+            if l := len(nodes); l == 0 || less(nodes[l-1].key, k) {
+                nodes = append(nodes, &RbMapNode{key: k, Value: v})
+                continue
+            }
+            t.build(nodes)
+            sorted = false
+        }
+        t.Insert(k, v)
+    }
+    if sorted {
+        t.build(nodes)
+    }
+    return t
+}
+
+// Create new balanced RbMap with keys of t and values replaced with
+// f(key, value). Entries are transformed during a single in-order walk and
+// linked into the new tree in O(n). t is unchanged.
+func (t *RbMap) TransformRebuild(f func(key, value interface{}) interface{}) *RbMap {
+    nodes := make([]*RbMapNode, 0, t.size)
+    for n := t.First(); n != nil; n = n.Next() {
+        nodes = append(nodes, &RbMapNode{key: n.key, Value: f(n.key, n.Value)})
+    }
+    r := NewRbMap(t.less)
+    r.build(nodes)
+    return r
+}
+
+// Create new RbMap with entries received from ch until it is closed. Keys
+// must come in strictly ascending order; each entry is attached as the
+// rightmost node and rebalanced as in Insert, but without a search from
+// the root. Returns error wrapping ErrNotSorted and naming the offending key
+// if the order is violated; the remaining entries are then read from ch and
+// discarded until it is closed, so that the sender is not blocked forever.
+func BuildFromSortedChan(less LessFunc, ch <-chan Entry) (*RbMap, error) {
+    t := NewRbMap(less)
+    var last *RbMapNode
+    for e := range ch {
+        if last != nil && !less(last.key, e.Key) {
+            for range ch {
+            }
+            return nil, fmt.Errorf("%w: %v", ErrNotSorted, e.Key)
+        }
+        z := &RbMapNode{parent: last, isred: true, key: e.Key, Value: e.Value, size: 1}
+        if last == nil {
+            t.root = z
+        } else {
+            last.right = z
+        }
+        for y := last; y != nil; y = y.parent {
+            y.size++
+        }
+        t.rb_insert_fixup(z)
+        t.size++
+        last = z
+    }
+    return t, nil
+}
+
+// Rebuild the tree so that keys in [hotLo, hotHi) are closer to the root,
+// for workloads which mostly look up keys in this range. The result is
+// still a valid red-black tree: at every level, the subtree root is picked
+// as close to the middle of the hot keys as red-black constraints allow,
+// while subtrees without hot keys are kept balanced. Lookup depth outside
+// of the hot range grows accordingly, but stays within the usual
+// red-black bound. Nodes are relinked in place, so node pointers stay
+// valid. Costs O(n).
+func (t *RbMap) BiasToward(hotLo, hotHi interface{}) {
+    nodes := make([]*RbMapNode, 0, t.size)
+    for n := t.First(); n != nil; n = n.Next() {
+        nodes = append(nodes, n)
+    }
+    b := biasBuilder{nodes: nodes}
+    b.hotLo = sort.Search(len(nodes), func(i int) bool { return !t.less(nodes[i].key, hotLo) })
+    b.hotHi = sort.Search(len(nodes), func(i int) bool { return !t.less(nodes[i].key, hotHi) })
+    // any black height allowed for the tree size will do, pick the one
+    // which lets the root be closest to the hot keys
+    n, best, miss := len(nodes), 0, -1
+    for h := 0; 1<<h-1 <= n; h++ {
+        if n > 1<<(2*h)-1 {
+            continue
+        }
+        if _, d := b.pickBlack(0, n, h); miss < 0 || d < miss {
+            best, miss = h, d
+        }
+    }
+    t.root = b.black(0, n, best, nil)
+}
+
+// Builds red-black subtrees of given black height h (number of black nodes
+// on every path down from subtree root, excluding nil leaves). A subtree
+// with black root may hold 2^h-1 to 4^h-1 nodes, and one with red root
+// 2^(h+1)-1 to 2*4^h-1 nodes.
+type biasBuilder struct {
+    nodes        []*RbMapNode
+    hotLo, hotHi int // hot nodes are nodes[hotLo:hotHi]
+}
+
+// Build subtree with black root out of nodes[from:to].
+func (b *biasBuilder) black(from, to, h int, parent *RbMapNode) *RbMapNode {
+    if from == to {
+        return nil
+    }
+    m, _ := b.pickBlack(from, to, h)
+    x := b.link(m, from, to, parent, false)
+    x.left = b.any(from, m, h-1, x)
+    x.right = b.any(m+1, to, h-1, x)
+    return x
+}
+
+// Build subtree of either color out of nodes[from:to], choosing the color
+// which lets the root be closer to hot nodes.
+func (b *biasBuilder) any(from, to, h int, parent *RbMapNode) *RbMapNode {
+    n := to - from
+    canBlack, canRed := n <= 1<<(2*h)-1, n >= 2<<h-1
+    if canBlack && canRed {
+        _, db := b.pickBlack(from, to, h)
+        _, dr := b.pickRed(from, to, h)
+        canRed = dr < db
+    }
+    if !canRed {
+        return b.black(from, to, h, parent)
+    }
+    m, _ := b.pickRed(from, to, h)
+    x := b.link(m, from, to, parent, true)
+    x.left = b.black(from, m, h, x)
+    x.right = b.black(m+1, to, h, x)
+    return x
+}
+
+// Pick root position for black subtree: children have black height h-1
+// and either color.
+func (b *biasBuilder) pickBlack(from, to, h int) (m, miss int) {
+    if h == 0 {
+        return from, 0
+    }
+    return b.pick(from, to, 1<<(h-1)-1, 2<<(2*(h-1))-1)
+}
+
+// Pick root position for red subtree: children are black with the same
+// black height h.
+func (b *biasBuilder) pickRed(from, to, h int) (m, miss int) {
+    return b.pick(from, to, 1<<h-1, 1<<(2*h)-1)
+}
+
+// Pick subtree root position in [from, to) so that both children get
+// between lo and hi nodes. Target position is the middle of hot nodes in
+// the range, if any, else the middle of the range; returns the allowed
+// position nearest to the target and its distance from the target.
+func (b *biasBuilder) pick(from, to, lo, hi int) (m, miss int) {
+    target := (from + to) / 2
+    if hl, hh := max(b.hotLo, from), min(b.hotHi, to); hl < hh {
+        target = (hl + hh - 1) / 2
+    }
+    n := to - from
+    m = min(max(target, from+max(lo, n-1-hi)), from+min(hi, n-1-lo))
+    if m < target {
+        return m, target - m
+    }
+    return m, m - target
+}
+
+func (b *biasBuilder) link(m, from, to int, parent *RbMapNode, red bool) *RbMapNode {
+    x := b.nodes[m]
+    x.parent, x.isred, x.size = parent, red, to-from
+    return x
+}
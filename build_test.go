@@ -0,0 +1,154 @@
+package rbt
+
+import (
+    "errors"
+    "testing"
+)
+
+func TestInsertManyUnique(t *testing.T) {
+    r := newstrtree("a", "b")
+    n, err := r.InsertManyUnique([]interface{}{"c", "d"}, []interface{}{2, 3})
+    if n != 2 || err != nil || r.Find("d") != 3 {
+        t.Fatalf("unique batch: %d, %v", n, err)
+    }
+    for _, keys := range [][]interface{}{{"e", "a"}, {"e", "f", "e"}} {
+        n, err = r.InsertManyUnique(keys, make([]interface{}, len(keys)))
+        if n != 0 || !errors.Is(err, ErrDuplicateKey) || r.Size() != 4 {
+            t.Fatalf("duplicate batch %v: %d, %v", keys, n, err)
+        }
+    }
+    if _, err = r.InsertManyUnique([]interface{}{"x"}, nil); err == nil || r.Size() != 4 {
+        t.Fatalf("mismatched lengths accepted")
+    }
+}
+
+func TestCollect(t *testing.T) {
+    r := newtree(t, 1000)
+    c := Collect(r.less, r.All())
+    c.verify()
+    if c.Size() != r.Size() {
+        t.Fatalf("collected size %d, want %d", c.Size(), r.Size())
+    }
+    for k, v := range c.All() {
+        if r.Find(k) != v {
+            t.Fatalf("key %v: got %v, want %v", k, v, r.Find(k))
+        }
+    }
+    // unsorted tail, with a duplicate key
+    seq := func(yield func(k, v interface{}) bool) {
+        for _, k := range []int{1, 3, 5, 2, 4, 3} {
+            if !yield(k, k*10) {
+                return
+            }
+        }
+    }
+    u := Collect(r.less, seq)
+    u.verify()
+    if u.Size() != 5 || u.Find(3) != 30 || u.First().Key() != 1 || u.Last().Key() != 5 {
+        t.Fatalf("unsorted collect: size %d", u.Size())
+    }
+    for k := range u.All() {
+        if k == 3 {
+            break
+        }
+    }
+}
+
+func TestTransformRebuild(t *testing.T) {
+    r := newinttree()
+    for i := 0; i < 1000; i++ {
+        r.Insert(i, i)
+    }
+    for i := 0; i < 1000; i += 3 {
+        r.Delete(i)
+    }
+    x := r.TransformRebuild(func(k, v interface{}) interface{} { return v.(int) * 2 })
+    x.verify()
+    if x.Size() != r.Size() || x.Height() > r.Height() {
+        t.Fatalf("rebuild: size %d, height %d", x.Size(), x.Height())
+    }
+    for n := r.First(); n != nil; n = n.Next() {
+        if x.Find(n.Key()) != n.Value.(int)*2 {
+            t.Fatalf("rebuild: key %v has value %v", n.Key(), x.Find(n.Key()))
+        }
+    }
+    if r.Find(1) != 1 {
+        t.Fatalf("source tree modified")
+    }
+}
+
+func TestBuildFromSortedChan(t *testing.T) {
+    less := func(a, b interface{}) bool { return a.(int) < b.(int) }
+    ch := make(chan Entry)
+    go func() {
+        for i := 0; i < 1000; i++ {
+            ch <- Entry{i * 2, i}
+        }
+        close(ch)
+    }()
+    r, err := BuildFromSortedChan(less, ch)
+    if err != nil {
+        t.Fatalf("build: %v", err)
+    }
+    r.verify()
+    if r.Size() != 1000 || r.Find(500) != 250 || r.Last().Key() != 1998 {
+        t.Fatalf("build: wrong contents")
+    }
+    ch = make(chan Entry)
+    done := make(chan bool)
+    go func() {
+        for _, k := range []int{1, 3, 3, 4, 5} {
+            ch <- Entry{k, nil}
+        }
+        close(ch)
+        done <- true
+    }()
+    if _, err := BuildFromSortedChan(less, ch); !errors.Is(err, ErrNotSorted) {
+        t.Fatalf("duplicate key: got %v", err)
+    }
+    <-done // sender is not left blocked
+    ch = make(chan Entry)
+    close(ch)
+    if r, err := BuildFromSortedChan(less, ch); err != nil || r.Size() != 0 {
+        t.Fatalf("empty channel: %v", err)
+    }
+}
+
+func TestBiasToward(t *testing.T) {
+    for _, size := range []int{0, 1, 2, 3, 5, 16, 100, 1000, 4096} {
+        for _, hot := range [][2]int{{0, 1}, {size / 2, size/2 + 3}, {size - 5, size}, {-10, -5}} {
+            r := newinttree()
+            for i := 0; i < size; i++ {
+                r.Insert(i, i)
+            }
+            depth := 0
+            for k := hot[0]; k < hot[1]; k++ {
+                depth += r.DepthOf(k)
+            }
+            n := r.First()
+            r.BiasToward(hot[0], hot[1])
+            r.verify()
+            if err := r.CheckParents(); err != nil {
+                t.Fatalf("size %d, hot %v: %v", size, hot, err)
+            }
+            if r.First() != n || r.Size() != size {
+                t.Fatalf("size %d, hot %v: nodes not preserved", size, hot)
+            }
+            biased := 0
+            for k := hot[0]; k < hot[1]; k++ {
+                biased += r.DepthOf(k)
+            }
+            if biased > depth {
+                t.Fatalf("size %d, hot %v: total depth grew from %d to %d", size, hot, depth, biased)
+            }
+        }
+    }
+    r := newinttree()
+    for i := 0; i < 1000; i++ {
+        r.Insert(i, i)
+    }
+    r.BiasToward(700, 701)
+    if d := r.DepthOf(700); d != 0 {
+        t.Fatalf("single hot key at depth %d", d)
+    }
+}
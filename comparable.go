@@ -0,0 +1,106 @@
+package rbt
+
+import (
+    "cmp"
+)
+
+// ComparableMap is RbMapG for keys of ordered types (integers, floats,
+// strings). Searches compare keys with built-in < operator, so no comparison
+// function is called, which makes lookups and inserts faster than in RbMapG
+// with a comparison function. Rebalancing, deletion and iteration are
+// shared with RbMapG, and all its methods are available.
+// Note: all methods are not goroutine-safe.
+type ComparableMap[K cmp.Ordered, V any] struct {
+    RbMapG[K, V]
+}
+
+// Create new empty ComparableMap.
+func NewComparableMap[K cmp.Ordered, V any]() *ComparableMap[K, V] {
+    return &ComparableMap[K, V]{RbMapG[K, V]{less: func(a, b K) bool { return a < b }}}
+}
+
+// Find value by key. Returns zero value and false if key not found.
+func (t *ComparableMap[K, V]) Find(key K) (V, bool) {
+    if n := t.FindNode(key); n != nil {
+        return n.Value, true
+    }
+    var zero V
+    return zero, false
+}
+
+// Find a node by key, returns nil if not found.
+func (t *ComparableMap[K, V]) FindNode(key K) *RbMapNodeG[K, V] {
+    x := t.root
+    for x != nil {
+        if x.key < key {
+            x = x.right
+        } else if key < x.key {
+            x = x.left
+        } else {
+            return x
+        }
+    }
+    return nil
+}
+
+// Find the first node with key not less than the given key, returns nil if
+// there is no such node.
+func (t *ComparableMap[K, V]) LowerBound(key K) *RbMapNodeG[K, V] {
+    var y *RbMapNodeG[K, V]
+    x := t.root
+    for x != nil {
+        if x.key < key {
+            x = x.right
+        } else {
+            y = x
+            x = x.left
+        }
+    }
+    return y
+}
+
+// Find the first node with key greater than the given key, returns nil if
+// there is no such node.
+func (t *ComparableMap[K, V]) UpperBound(key K) *RbMapNodeG[K, V] {
+    var y *RbMapNodeG[K, V]
+    x := t.root
+    for x != nil {
+        if key < x.key {
+            y = x
+            x = x.left
+        } else {
+            x = x.right
+        }
+    }
+    return y
+}
+
+// Insert key and value into the tree. If new entry is created, returns true.
+// If key already exists, value gets replaced and Insert returns false.
+func (t *ComparableMap[K, V]) Insert(key K, value V) bool {
+    x := t.root
+    var y *RbMapNodeG[K, V]
+    left := false
+    for x != nil {
+        y = x
+        if x.key < key {
+            x, left = x.right, false
+        } else if key < x.key {
+            x, left = x.left, true
+        } else {
+            x.Value = value
+            return false
+        }
+    }
+    t.attach(y, left, key, value)
+    return true
+}
+
+// Delete tree node by key. Returns true if key was found and deleted.
+func (t *ComparableMap[K, V]) Delete(key K) bool {
+    if z := t.FindNode(key); z != nil {
+        t.DeleteNode(z)
+        return true
+    }
+    return false
+}
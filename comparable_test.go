@@ -0,0 +1,86 @@
+package rbt
+
+import (
+    "math/rand"
+    "testing"
+)
+
+func TestComparableMap(t *testing.T) {
+    r := NewComparableMap[int, string]()
+    ref := make(map[int]string)
+    for i := 0; i < 100000; i++ {
+        k := rand.Intn(5000)
+        if rand.Intn(3) == 0 {
+            _, exists := ref[k]
+            if r.Delete(k) != exists {
+                t.Fatalf("delete %d: wrong result", k)
+            }
+            delete(ref, k)
+        } else {
+            _, exists := ref[k]
+            if r.Insert(k, "v") == exists {
+                t.Fatalf("insert %d: wrong result", k)
+            }
+            ref[k] = "v"
+        }
+        if i%10000 == 0 {
+            r.verify()
+        }
+    }
+    r.verify()
+    if r.Size() != len(ref) {
+        t.Fatalf("size %d, want %d", r.Size(), len(ref))
+    }
+    cnt, prev := 0, -1
+    for n := r.First(); n != nil; n = n.Next() {
+        if n.Key() <= prev {
+            t.Fatalf("keys out of order")
+        }
+        if _, ok := r.Find(n.Key()); !ok {
+            t.Fatalf("key %d not found", n.Key())
+        }
+        prev = n.Key()
+        cnt++
+    }
+    for n := r.Last(); n != nil; n = n.Prev() {
+        cnt--
+    }
+    if cnt != 0 {
+        t.Fatalf("forward and backward iteration differ")
+    }
+    if _, ok := r.Find(-1); ok {
+        t.Fatalf("missing key found")
+    }
+    if n := r.LowerBound(-1); n != r.First() || r.UpperBound(r.Last().Key()) != nil {
+        t.Fatalf("bounds at the ends")
+    }
+    if n := r.UpperBound(r.First().Key()); n != r.First().Next() {
+        t.Fatalf("upper bound of first key")
+    }
+}
+
+func BenchmarkInsertInterface(b *testing.B) {
+    b.ReportAllocs()
+    r := NewRbMap(func(k1, k2 interface{}) bool {
+        return k1.(int) < k2.(int)
+    })
+    for i := 0; i < b.N; i++ {
+        r.Insert(i*7919%1000003, i)
+    }
+}
+
+func BenchmarkInsertGeneric(b *testing.B) {
+    b.ReportAllocs()
+    r := NewOrdered[int, int]()
+    for i := 0; i < b.N; i++ {
+        r.Insert(i*7919%1000003, i)
+    }
+}
+
+func BenchmarkInsertComparable(b *testing.B) {
+    b.ReportAllocs()
+    r := NewComparableMap[int, int]()
+    for i := 0; i < b.N; i++ {
+        r.Insert(i*7919%1000003, i)
+    }
+}
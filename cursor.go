@@ -0,0 +1,101 @@
+package rbt
+
+// Cursor is a movable position in the tree. Unlike plain node iteration,
+// cursor can look at the upcoming entry without moving, which is handy for
+// merge and join algorithms over several trees. Deleting any entry of the
+// tree may invalidate the cursor, as deletion can move entries between
+// nodes; the only exception is the entry right before the cursor. So to
+// delete entries while walking, advance the cursor first, then delete the
+// node it left.
+type Cursor struct {
+    node *RbMapNode
+}
+
+// Create cursor positioned at the first entry of the tree. Cursor is not
+// valid if the tree is empty.
+func (t *RbMap) Cursor() *Cursor {
+    return &Cursor{node: t.First()}
+}
+
+// Create cursor positioned at the given node.
+func (n *RbMapNode) Cursor() *Cursor {
+    return &Cursor{node: n}
+}
+
+// Returns true if cursor points to an entry.
+func (c *Cursor) Valid() bool {
+    return c.node != nil
+}
+
+// Returns node the cursor points to, nil if cursor is not valid.
+func (c *Cursor) Node() *RbMapNode {
+    return c.node
+}
+
+// Returns key of the current entry. Cursor must be valid.
+func (c *Cursor) Key() interface{} {
+    return c.node.key
+}
+
+// Returns value of the current entry. Cursor must be valid.
+func (c *Cursor) Value() interface{} {
+    return c.node.Value
+}
+
+// Move to the next entry in ascending key order. Returns false if there is
+// no next entry, after which cursor is not valid.
+func (c *Cursor) Next() bool {
+    if c.node != nil {
+        c.node = c.node.Next()
+    }
+    return c.node != nil
+}
+
+// Move to the previous entry in ascending key order. Returns false if there
+// is no previous entry, after which cursor is not valid.
+func (c *Cursor) Prev() bool {
+    if c.node != nil {
+        c.node = c.node.Prev()
+    }
+    return c.node != nil
+}
+
+// Returns key of the entry following the current one without moving the
+// cursor; ok is false if there is no such entry or cursor is not valid.
+func (c *Cursor) PeekNextKey() (key interface{}, ok bool) {
+    if c.node == nil {
+        return nil, false
+    }
+    if n := c.node.Next(); n != nil {
+        return n.key, true
+    }
+    return nil, false
+}
+
+// SumCursor yields entries in ascending key order together with running
+// sum of their values, see PrefixSumCursor. Like Cursor, it may become
+// invalid if any entry of the tree is deleted, except for the entry it
+// returned last.
+type SumCursor struct {
+    node  *RbMapNode
+    value func(interface{}) float64
+    sum   float64
+}
+
+// Create cursor yielding cumulative sums of value(node.Value), starting
+// from the first entry.
+func (t *RbMap) PrefixSumCursor(value func(interface{}) float64) *SumCursor {
+    return &SumCursor{node: t.First(), value: value}
+}
+
+// Advance to the next entry and return its key with the sum of values of
+// all entries up to and including it; ok is false when entries run out.
+func (c *SumCursor) Next() (key interface{}, runningSum float64, ok bool) {
+    if c.node == nil {
+        return nil, c.sum, false
+    }
+    n := c.node
+    c.node = n.Next()
+    c.sum += c.value(n.Value)
+    return n.key, c.sum, true
+}
@@ -0,0 +1,99 @@
+package rbt
+
+import (
+    "testing"
+)
+
+func TestCursorPeek(t *testing.T) {
+    r := newstrtree("a", "b", "c")
+    c := r.Cursor()
+    var got string
+    for ; c.Valid(); c.Next() {
+        got += c.Key().(string)
+        k, ok := c.PeekNextKey()
+        if c.Key() == "c" {
+            if ok {
+                t.Fatalf("peek past last entry returned %v", k)
+            }
+        } else if !ok || k.(string) != string(c.Key().(string)[0]+1) {
+            t.Fatalf("peek after %v returned %v, %v", c.Key(), k, ok)
+        }
+    }
+    if got != "abc" {
+        t.Fatalf("cursor walk: got %q", got)
+    }
+    if _, ok := c.PeekNextKey(); ok {
+        t.Fatalf("peek on invalid cursor")
+    }
+    c = r.Last().Cursor()
+    if !c.Prev() || c.Key() != "b" || c.Value() != 1 {
+        t.Fatalf("cursor prev")
+    }
+    if _, ok := newstrtree().Cursor().PeekNextKey(); ok {
+        t.Fatalf("peek on empty tree")
+    }
+}
+
+func TestPrefixSumCursor(t *testing.T) {
+    r := newstrtree("a", "b", "c", "d")
+    c := r.PrefixSumCursor(func(v interface{}) float64 { return float64(v.(int)) })
+    var keys string
+    var sums []float64
+    for k, s, ok := c.Next(); ok; k, s, ok = c.Next() {
+        keys += k.(string)
+        sums = append(sums, s)
+    }
+    if keys != "abcd" || len(sums) != 4 || sums[0] != 0 || sums[1] != 1 || sums[2] != 3 || sums[3] != 6 {
+        t.Fatalf("prefix sums: got %q, %v", keys, sums)
+    }
+    if _, s, ok := c.Next(); ok || s != 6 {
+        t.Fatalf("prefix sum past the end: %v, %v", s, ok)
+    }
+    if _, _, ok := newstrtree().PrefixSumCursor(nil).Next(); ok {
+        t.Fatalf("prefix sum of empty tree")
+    }
+}
+
+func TestCursorDelete(t *testing.T) {
+    r := newinttree()
+    for i := 0; i < 1000; i++ {
+        r.Insert(i, i)
+    }
+    var got []int
+    for c := r.Cursor(); c.Valid(); {
+        n := c.Node()
+        got = append(got, n.Key().(int))
+        c.Next()
+        if n.Key().(int)%3 != 0 {
+            r.DeleteNode(n) // entry right before the cursor
+        }
+    }
+    r.verify()
+    if len(got) != 1000 || r.Size() != 334 {
+        t.Fatalf("visited %d entries, %d left", len(got), r.Size())
+    }
+    for i, k := range got {
+        if k != i {
+            t.Fatalf("entry %d visited as %d", i, k)
+        }
+    }
+}
+
+func TestPrefixSumCursorDelete(t *testing.T) {
+    r := newinttree()
+    for i := 0; i < 100; i++ {
+        r.Insert(i, i)
+    }
+    c := r.PrefixSumCursor(func(v interface{}) float64 { return float64(v.(int)) })
+    cnt := 0
+    for k, s, ok := c.Next(); ok; k, s, ok = c.Next() {
+        if s != float64(k.(int)*(k.(int)+1)/2) {
+            t.Fatalf("prefix sum at %v: got %v", k, s)
+        }
+        r.Delete(k) // entry returned last
+        cnt++
+    }
+    if cnt != 100 || r.Size() != 0 {
+        t.Fatalf("visited %d entries, %d left", cnt, r.Size())
+    }
+}
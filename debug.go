@@ -0,0 +1,254 @@
+package rbt
+
+import (
+    "errors"
+    "fmt"
+    "math"
+)
+
+// NodeInfo is a detached copy of a tree node with its subtrees, suitable
+// for visualization and serialization of tree shape.
+type NodeInfo struct {
+    Key, Value  interface{}
+    Red         bool
+    Left, Right *NodeInfo
+}
+
+// Returns copy of the tree structure, including node colors. Returns nil
+// for empty tree.
+func (t *RbMap) Structure() *NodeInfo {
+    return structure(t.root)
+}
+
+func structure(n *RbMapNode) *NodeInfo {
+    if n == nil {
+        return nil
+    }
+    return &NodeInfo{
+        Key:   n.key,
+        Value: n.Value,
+        Red:   n.isred,
+        Left:  structure(n.left),
+        Right: structure(n.right),
+    }
+}
+
+// NodeColor is color and depth (number of edges from the root) of a tree
+// node, as reported by ColorProfile.
+type NodeColor struct {
+    Red   bool
+    Depth int
+}
+
+// Returns color and depth of every node, in ascending key order.
+func (t *RbMap) ColorProfile() []NodeColor {
+    r := make([]NodeColor, 0, t.size)
+    var walk func(n *RbMapNode, depth int)
+    walk = func(n *RbMapNode, depth int) {
+        if n == nil {
+            return
+        }
+        walk(n.left, depth+1)
+        r = append(r, NodeColor{n.isred, depth})
+        walk(n.right, depth+1)
+    }
+    walk(t.root, 0)
+    return r
+}
+
+// Check parent/child linkage of all nodes: every child must point back to
+// its parent, root must have no parent, following parent pointers from any
+// node must reach the root, and the number of reachable nodes must match
+// Size(). Returns error describing the first problem found, nil if the tree
+// is consistent.
+func (t *RbMap) CheckParents() error {
+    if t.root == nil {
+        if t.size != 0 {
+            return fmt.Errorf("rbt: empty tree has size %d", t.size)
+        }
+        return nil
+    }
+    if t.root.parent != nil {
+        return errors.New("rbt: root has parent")
+    }
+    cnt := 0
+    stack := []*RbMapNode{t.root}
+    for len(stack) > 0 {
+        n := stack[len(stack)-1]
+        stack = stack[:len(stack)-1]
+        if cnt++; cnt > t.size {
+            return fmt.Errorf("rbt: more than %d nodes reachable, tree has a cycle", t.size)
+        }
+        for _, c := range []*RbMapNode{n.left, n.right} {
+            if c == nil {
+                continue
+            }
+            if c.parent != n {
+                return fmt.Errorf("rbt: child %v of node %v has wrong parent", c.key, n.key)
+            }
+            stack = append(stack, c)
+        }
+        p, steps := n, 0
+        for ; p.parent != nil && steps <= t.size; steps++ {
+            p = p.parent
+        }
+        if p != t.root {
+            return fmt.Errorf("rbt: parent chain of node %v does not reach root", n.key)
+        }
+    }
+    if cnt != t.size {
+        return fmt.Errorf("rbt: %d nodes reachable, tree size is %d", cnt, t.size)
+    }
+    return nil
+}
+
+// Check subtree sizes maintained in tree nodes, recomputing them bottom-up.
+// Returns error naming the first node with wrong size, nil if all sizes,
+// including the tree size, are correct.
+func (t *RbMap) CheckSizes() error {
+    s, err := checkSizes(t.root)
+    if err == nil && s != t.size {
+        err = fmt.Errorf("rbt: tree size is %d, but has %d nodes", t.size, s)
+    }
+    return err
+}
+
+// Iterate over entries in ascending key order together with subtree sizes
+// maintained in their nodes, until f returns false. Meant for inspecting
+// the size augmentation used by position queries.
+func (t *RbMap) RangeWithSize(f func(key, value interface{}, subtreeSize int) bool) {
+    for n := t.First(); n != nil; n = n.Next() {
+        if !f(n.key, n.Value, n.size) {
+            return
+        }
+    }
+}
+
+func checkSizes(n *RbMapNode) (int, error) {
+    if n == nil {
+        return 0, nil
+    }
+    l, err := checkSizes(n.left)
+    if err != nil {
+        return 0, err
+    }
+    r, err := checkSizes(n.right)
+    if err != nil {
+        return 0, err
+    }
+    if l+r+1 != n.size {
+        return 0, fmt.Errorf("rbt: node %v has size %d, but %d nodes in subtree", n.key, n.size, l+r+1)
+    }
+    return n.size, nil
+}
+
+// Check that Next and Prev are inverse of each other for every node, and
+// that in-order walk visits Size() nodes. Returns error at the first
+// failure, nil if navigation is consistent.
+func (t *RbMap) checkNavigation() error {
+    cnt := 0
+    for n := t.First(); n != nil; n = n.Next() {
+        if cnt++; cnt > t.size {
+            return fmt.Errorf("rbt: in-order walk visits more than %d nodes", t.size)
+        }
+        if next := n.Next(); next != nil && next.Prev() != n {
+            return fmt.Errorf("rbt: Next().Prev() of node %v is not the node", n.key)
+        }
+        if prev := n.Prev(); prev != nil && prev.Next() != n {
+            return fmt.Errorf("rbt: Prev().Next() of node %v is not the node", n.key)
+        }
+    }
+    if cnt != t.size {
+        return fmt.Errorf("rbt: in-order walk visits %d nodes, tree size is %d", cnt, t.size)
+    }
+    return nil
+}
+
+// Returns true if both trees have identical shape and node colors, and
+// corresponding nodes have equal keys (under t's comparison function).
+// Values are not compared. Unlike content comparison, this tells apart trees
+// holding the same keys in different layouts, which is useful for testing
+// tree construction.
+func (t *RbMap) StructuralEqual(other *RbMap) bool {
+    return t.size == other.size && t.structuralEqual(t.root, other.root)
+}
+
+func (t *RbMap) structuralEqual(a, b *RbMapNode) bool {
+    if a == nil || b == nil {
+        return a == b
+    }
+    return a.isred == b.isred &&
+        !t.less(a.key, b.key) && !t.less(b.key, a.key) &&
+        t.structuralEqual(a.left, b.left) &&
+        t.structuralEqual(a.right, b.right)
+}
+
+// Returns true if both trees hold equal keys (under t's comparison
+// function) with equal values. Values are compared with ==, so their
+// dynamic types must be comparable. Comparing a tree with itself returns
+// at once; otherwise this degrades to a full in-order walk, O(n), because
+// RbMap nodes have parent links and are never shared between trees, so
+// there are no shared subtrees to skip. Versions of VersionedRbMap do share
+// nodes, see VersionedRbMap.EqualFast.
+func (t *RbMap) EqualFast(other *RbMap) bool {
+    if t.size != other.size {
+        return false
+    }
+    if t.root == other.root {
+        return true
+    }
+    a, b := t.First(), other.First()
+    for ; a != nil && b != nil; a, b = a.Next(), b.Next() {
+        if t.less(a.key, b.key) || t.less(b.key, a.key) || a.Value != b.Value {
+            return false
+        }
+    }
+    return a == nil && b == nil
+}
+
+// Returns tree height: number of nodes on the longest path from the root to
+// a leaf, 0 for empty tree. Costs O(n).
+func (t *RbMap) Height() int {
+    return height(t.root)
+}
+
+func height(n *RbMapNode) int {
+    if n == nil {
+        return 0
+    }
+    l, r := height(n.left), height(n.right)
+    if l < r {
+        l = r
+    }
+    return l + 1
+}
+
+type heightGuard struct {
+    every, ops  int
+    onViolation func(height, bound int)
+}
+
+// Enable periodic check of tree height against red-black tree bound
+// 2*log2(Size()+1)+1, as a safety net against balancing bugs and memory
+// corruption. After every `every` inserts and deletes, tree height is
+// computed in O(n), and if it exceeds the bound, onViolation is called with
+// the height and the bound. Passing nil onViolation or every < 1 disables
+// the check, which is the default.
+func (t *RbMap) SetHeightGuard(every int, onViolation func(height, bound int)) {
+    if every < 1 || onViolation == nil {
+        t.guard = nil
+        return
+    }
+    t.guard = &heightGuard{every: every, onViolation: onViolation}
+}
+
+func (g *heightGuard) check(t *RbMap) {
+    if g.ops++; g.ops < g.every {
+        return
+    }
+    g.ops = 0
+    bound := int(2*math.Log2(float64(t.size+1))) + 1
+    if h := t.Height(); h > bound {
+        g.onViolation(h, bound)
+    }
+}
@@ -0,0 +1,214 @@
+package rbt
+
+import (
+    "testing"
+)
+
+func TestStructure(t *testing.T) {
+    if newinttree().Structure() != nil {
+        t.Fatalf("structure of empty tree")
+    }
+    r := newtree(t, 1000)
+    var check func(n *RbMapNode, s *NodeInfo)
+    check = func(n *RbMapNode, s *NodeInfo) {
+        if (n == nil) != (s == nil) {
+            t.Fatalf("shape mismatch")
+        }
+        if n == nil {
+            return
+        }
+        if n.Key() != s.Key || n.Value != s.Value || n.isred != s.Red {
+            t.Fatalf("node %v mismatch", n.Key())
+        }
+        check(n.left, s.Left)
+        check(n.right, s.Right)
+    }
+    check(r.root, r.Structure())
+}
+
+func TestColorProfile(t *testing.T) {
+    got := newinttree(1, 2, 3, 4).ColorProfile()
+    want := []NodeColor{{false, 1}, {false, 0}, {false, 1}, {true, 2}}
+    if len(got) != len(want) {
+        t.Fatalf("color profile: got %v", got)
+    }
+    for i := range want {
+        if got[i] != want[i] {
+            t.Fatalf("color profile: got %v, want %v", got, want)
+        }
+    }
+    if len(newinttree().ColorProfile()) != 0 {
+        t.Fatalf("color profile of empty tree")
+    }
+}
+
+func TestCheckParents(t *testing.T) {
+    r := newtree(t, 1000)
+    if err := r.CheckParents(); err != nil {
+        t.Fatalf("valid tree: %v", err)
+    }
+    if err := newinttree().CheckParents(); err != nil {
+        t.Fatalf("empty tree: %v", err)
+    }
+    n := r.root.left
+    p := n.left.parent
+    n.left.parent = r.root
+    if err := r.CheckParents(); err == nil {
+        t.Fatalf("wrong parent not detected")
+    }
+    n.left.parent = p
+    l := n.left
+    n.left = r.root // cycle through child link
+    if err := r.CheckParents(); err == nil {
+        t.Fatalf("cycle not detected")
+    }
+    n.left = l
+    r.size++
+    if err := r.CheckParents(); err == nil {
+        t.Fatalf("size mismatch not detected")
+    }
+}
+
+func TestCheckSizes(t *testing.T) {
+    r := newtree(t, 1000)
+    for i := 0; i < 300; i++ {
+        r.DeleteNode(r.root)
+        if err := r.CheckSizes(); err != nil {
+            t.Fatalf("after delete: %v", err)
+        }
+    }
+    r.root.left.right.size++
+    if err := r.CheckSizes(); err == nil {
+        t.Fatalf("wrong subtree size not detected")
+    }
+    r.root.left.right.size--
+    r.size--
+    if err := r.CheckSizes(); err == nil {
+        t.Fatalf("wrong tree size not detected")
+    }
+}
+
+func TestCheckNavigation(t *testing.T) {
+    r := newtree(t, 1000)
+    for i := 0; i < 300; i++ {
+        r.DeleteNode(r.root)
+    }
+    if err := r.checkNavigation(); err != nil {
+        t.Fatalf("valid tree: %v", err)
+    }
+    if err := newinttree().checkNavigation(); err != nil {
+        t.Fatalf("empty tree: %v", err)
+    }
+    m := r.root.right.min()
+    m.parent = r.root.left // Prev of successor of root skips root
+    if err := r.checkNavigation(); err == nil {
+        t.Fatalf("broken linkage not detected")
+    }
+}
+
+func TestRangeWithSize(t *testing.T) {
+    r := newinttree(1, 2, 3, 4, 5, 6, 7).TransformRebuild(func(k, v interface{}) interface{} { return v })
+    var got []int
+    r.RangeWithSize(func(k, v interface{}, size int) bool {
+        got = append(got, size)
+        return true
+    })
+    // perfectly balanced tree of 7 nodes
+    want := []int{1, 3, 1, 7, 1, 3, 1}
+    if len(got) != len(want) {
+        t.Fatalf("sizes: got %v", got)
+    }
+    for i := range want {
+        if got[i] != want[i] {
+            t.Fatalf("sizes: got %v, want %v", got, want)
+        }
+    }
+}
+
+func TestStructuralEqual(t *testing.T) {
+    a, b := newinttree(), newinttree()
+    for i := 0; i < 100; i++ {
+        a.Insert(i, []int{i})
+        b.Insert(i, []int{i})
+    }
+    if !a.StructuralEqual(b) || !newinttree().StructuralEqual(newinttree()) {
+        t.Fatalf("equal trees reported different")
+    }
+    b.Insert(5, []int{6})
+    if !a.StructuralEqual(b) {
+        t.Fatalf("values compared")
+    }
+    b.Delete(99)
+    b.Insert(100, []int{99})
+    if a.StructuralEqual(b) {
+        t.Fatalf("different key not detected")
+    }
+    // same contents, different insertion order and shape
+    c := newinttree()
+    for i := 99; i >= 0; i-- {
+        c.Insert(i, []int{i})
+    }
+    if a.StructuralEqual(c) {
+        t.Fatalf("different shape not detected")
+    }
+}
+
+func TestEqualFast(t *testing.T) {
+    a, c := newinttree(), newinttree()
+    for i := 0; i < 100; i++ {
+        a.Insert(i, i)
+        c.Insert(99-i, 99-i)
+    }
+    if !a.EqualFast(a) || !a.EqualFast(c) || !newinttree().EqualFast(newinttree()) {
+        t.Fatalf("equal trees reported different")
+    }
+    c.Insert(5, 6)
+    if a.EqualFast(c) {
+        t.Fatalf("different value not detected")
+    }
+    c.Insert(5, 5)
+    c.Delete(99)
+    c.Insert(100, 99)
+    if a.EqualFast(c) || a.EqualFast(newinttree(1)) {
+        t.Fatalf("different keys not detected")
+    }
+}
+
+func TestHeightGuard(t *testing.T) {
+    r := newinttree()
+    if r.Height() != 0 {
+        t.Fatalf("height of empty tree")
+    }
+    checks := 0
+    r.SetHeightGuard(10, func(h, bound int) {
+        t.Fatalf("height %d exceeds bound %d", h, bound)
+    })
+    for i := 0; i < 1000; i++ {
+        r.Insert(i, i)
+    }
+    for i := 0; i < 500; i++ {
+        r.Delete(i * 2)
+    }
+    // unbalance the tree by hand: hang a long chain off the last node
+    n := r.Last()
+    for i := 0; i < 100; i++ {
+        c := &RbMapNode{key: 2000 + i, parent: n, size: 1}
+        n.right = c
+        n = c
+    }
+    r.SetHeightGuard(1, func(h, bound int) {
+        if h <= bound {
+            t.Fatalf("height %d within bound %d reported", h, bound)
+        }
+        checks++
+    })
+    r.Delete(1)
+    if checks != 1 {
+        t.Fatalf("violation reported %d times", checks)
+    }
+    r.SetHeightGuard(0, nil)
+    r.Delete(3)
+    if checks != 1 {
+        t.Fatalf("disabled guard reported violation")
+    }
+}
@@ -0,0 +1,132 @@
+package rbt
+
+// Delete entries from the beginning of the tree while pred holds for them.
+// Stops at the first entry for which pred returns false, so matching
+// entries after it are kept. Returns number of deleted entries.
+func (t *RbMap) TrimPrefix(pred func(key, value interface{}) bool) int {
+    cnt := 0
+    for n := t.First(); n != nil && pred(n.key, n.Value); n = t.First() {
+        t.DeleteNode(n)
+        cnt++
+    }
+    return cnt
+}
+
+// Delete entries from the end of the tree while pred holds for them. Stops
+// at the first entry (counting from the end) for which pred returns false.
+// Returns number of deleted entries.
+func (t *RbMap) TrimSuffix(pred func(key, value interface{}) bool) int {
+    cnt := 0
+    for n := t.Last(); n != nil && pred(n.key, n.Value); n = t.Last() {
+        t.DeleteNode(n)
+        cnt++
+    }
+    return cnt
+}
+
+// Decrement int value of the entry by the given amount, deleting the entry
+// if the result drops to zero or below. Returns the resulting count, whether
+// the entry was deleted, and whether key was found at all. Value of the
+// entry must be an int.
+func (t *RbMap) DecrementAndDelete(key interface{}, by int) (remaining int, deleted bool, found bool) {
+    n := t.FindNode(key)
+    if n == nil {
+        return 0, false, false
+    }
+    remaining = n.Value.(int) - by
+    if remaining <= 0 {
+        t.DeleteNode(n)
+        return remaining, true, true
+    }
+    n.Value = remaining
+    return remaining, false, true
+}
+
+// Delete entries which are expired at the given time, as reported by
+// expired(expiredAt(value), now). Returns number of deleted entries.
+func (t *RbMap) EvictExpired(now interface{}, expiredAt func(value interface{}) interface{}, expired func(a, b interface{}) bool) int {
+    cnt := 0
+    for n := t.First(); n != nil; {
+        // DeleteNode may move the predecessor into n, but never the
+        // successor, so it stays valid.
+        next := n.Next()
+        if expired(expiredAt(n.Value), now) {
+            t.DeleteNode(n)
+            cnt++
+        }
+        n = next
+    }
+    return cnt
+}
+
+// Delete entries by keys, returns number of deleted entries. If keys are
+// sorted in ascending order, they are matched against the tree in a single
+// merge walk, starting from the first key position, which saves a search
+// from the root per key: the walk costs O(log n + d), where d is the
+// distance walked in the tree, plus O(log n) per deleted entry, i.e.
+// O(log n + d + k log n) in total. This is fastest when the keys are dense
+// in the deleted range. Unsorted keys are deleted one by one, in
+// O(k log n).
+func (t *RbMap) DeleteMany(keys []interface{}) int {
+    if len(keys) == 0 {
+        return 0
+    }
+    for i := 1; i < len(keys); i++ {
+        if t.less(keys[i], keys[i-1]) {
+            cnt := 0
+            for _, k := range keys {
+                if t.Delete(k) {
+                    cnt++
+                }
+            }
+            return cnt
+        }
+    }
+    cnt := 0
+    n := t.LowerBound(keys[0])
+    for _, k := range keys {
+        for n != nil && t.less(n.key, k) {
+            n = n.Next()
+        }
+        if n == nil {
+            break
+        }
+        if !t.less(k, n.key) {
+            // successor node is not moved by deletion, unlike n itself
+            next := n.Next()
+            t.DeleteNode(n)
+            n = next
+            cnt++
+        }
+    }
+    return cnt
+}
+
+// Insert key and value, then evict entries which fell out of the window:
+// all entries with keys less than cutoff(newest), where newest is the
+// highest key in the tree. Returns number of evicted entries. The inserted
+// entry itself is evicted if it is older than the cutoff.
+func (t *RbMap) InsertWindowed(key, value interface{}, cutoff func(newest interface{}) interface{}) int {
+    t.Insert(key, value)
+    c := cutoff(t.Last().key)
+    cnt := 0
+    for n := t.First(); n != nil && t.less(n.key, c); n = t.First() {
+        t.DeleteNode(n)
+        cnt++
+    }
+    return cnt
+}
+
+// Remove up to n entries with the lowest keys from t and return them as a
+// new tree with the same comparison function. Entries are moved into the
+// new tree in O(n) after removal, without rebalancing on every insert.
+func (t *RbMap) TakeFirstN(n int) *RbMap {
+    var nodes []*RbMapNode
+    for f := t.First(); f != nil && len(nodes) < n; f = t.First() {
+        nodes = append(nodes, &RbMapNode{key: f.key, Value: f.Value})
+        t.DeleteNode(f)
+    }
+    r := NewRbMap(t.less)
+    r.build(nodes)
+    return r
+}
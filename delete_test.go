@@ -0,0 +1,131 @@
+package rbt
+
+import (
+    "testing"
+)
+
+func TestTrim(t *testing.T) {
+    r := newinttree(1, 2, 3, 10, 4, 11, 12)
+    small := func(k, v interface{}) bool { return k.(int) < 5 }
+    if n := r.TrimSuffix(small); n != 0 || r.Size() != 7 {
+        t.Fatalf("trim suffix removed %d", n)
+    }
+    if n := r.TrimPrefix(small); n != 4 || r.First().Key() != 10 {
+        t.Fatalf("trim prefix removed %d", n)
+    }
+    r.Insert(1, 1)
+    if n := r.TrimSuffix(func(k, v interface{}) bool { return k.(int) > 10 }); n != 2 || r.Size() != 2 {
+        t.Fatalf("trim suffix removed %d", n)
+    }
+    if n := r.TrimPrefix(func(k, v interface{}) bool { return true }); n != 2 || r.Size() != 0 {
+        t.Fatalf("trim all removed %d", n)
+    }
+    r.verify()
+}
+
+func TestDecrementAndDelete(t *testing.T) {
+    r := newinttree(5)
+    if rem, del, found := r.DecrementAndDelete(5, 2); rem != 3 || del || !found || r.Find(5) != 3 {
+        t.Fatalf("decrement: %d, %v, %v", rem, del, found)
+    }
+    if rem, del, found := r.DecrementAndDelete(5, 3); rem != 0 || !del || !found || r.Size() != 0 {
+        t.Fatalf("release: %d, %v, %v", rem, del, found)
+    }
+    if _, del, found := r.DecrementAndDelete(5, 1); del || found {
+        t.Fatalf("missing key found")
+    }
+}
+
+func TestEvictExpired(t *testing.T) {
+    r := newinttree()
+    for i := 0; i < 1000; i++ {
+        r.Insert(i, (i*7)%100) // value is expiry time
+    }
+    expiredAt := func(v interface{}) interface{} { return v }
+    before := func(a, b interface{}) bool { return a.(int) < b.(int) }
+    if n := r.EvictExpired(50, expiredAt, before); n != 500 {
+        t.Fatalf("evicted %d, want 500", n)
+    }
+    r.verify()
+    for n := r.First(); n != nil; n = n.Next() {
+        if n.Value.(int) < 50 {
+            t.Fatalf("key %v not evicted", n.Key())
+        }
+    }
+    if n := r.EvictExpired(100, expiredAt, before); n != 500 || r.Size() != 0 {
+        t.Fatalf("evicted %d, %d left", n, r.Size())
+    }
+}
+
+func TestDeleteMany(t *testing.T) {
+    for _, keys := range [][]interface{}{
+        {0, 2, 2, 4, 5, 6, 8, 100, 101}, // sorted, with duplicate and missing keys
+        {8, 0, 101, 2, 6, 5, 4, 100, 2}, // unsorted
+    } {
+        r := newinttree()
+        for i := 0; i < 100; i++ {
+            r.Insert(i, i)
+        }
+        if n := r.DeleteMany(keys); n != 6 || r.Size() != 94 {
+            t.Fatalf("delete many %v: deleted %d, size %d", keys, n, r.Size())
+        }
+        for _, k := range []int{0, 2, 4, 5, 6, 8} {
+            if r.FindNode(k) != nil {
+                t.Fatalf("key %d not deleted", k)
+            }
+        }
+        r.verify()
+    }
+    r := newtree(t, 10000)
+    var keys []interface{}
+    for n := r.First(); n != nil; n = n.Next() {
+        keys = append(keys, n.Key())
+    }
+    if n := r.DeleteMany(keys); n != len(keys) || r.Size() != 0 {
+        t.Fatalf("delete all: deleted %d of %d", n, len(keys))
+    }
+}
+
+func TestInsertWindowed(t *testing.T) {
+    r := newinttree()
+    window := func(newest interface{}) interface{} { return newest.(int) - 10 }
+    for _, k := range []int{1, 5, 9, 11} {
+        if n := r.InsertWindowed(k, k, window); n != 0 {
+            t.Fatalf("insert %d evicted %d", k, n)
+        }
+    }
+    if n := r.InsertWindowed(17, 17, window); n != 2 || r.First().Key() != 9 {
+        t.Fatalf("evicted %d, first key %v", n, r.First().Key())
+    }
+    if n := r.InsertWindowed(3, 3, window); n != 1 || r.Size() != 3 {
+        t.Fatalf("stale insert: evicted %d, size %d", n, r.Size())
+    }
+}
+
+func TestTakeFirstN(t *testing.T) {
+    r := newtree(t, 1000)
+    size := r.Size()
+    var keys []interface{}
+    for n := r.First(); len(keys) < 100; n = n.Next() {
+        keys = append(keys, n.Key())
+    }
+    f := r.TakeFirstN(100)
+    f.verify()
+    r.verify()
+    if f.Size() != 100 || r.Size() != size-100 {
+        t.Fatalf("sizes %d/%d", f.Size(), r.Size())
+    }
+    i := 0
+    for n := f.First(); n != nil; n = n.Next() {
+        if n.Key() != keys[i] || r.FindNode(n.Key()) != nil {
+            t.Fatalf("entry %d not moved", i)
+        }
+        i++
+    }
+    if f = r.TakeFirstN(size); f.Size() != size-100 || r.Size() != 0 {
+        t.Fatalf("take all: sizes %d/%d", f.Size(), r.Size())
+    }
+    if r.TakeFirstN(5).Size() != 0 {
+        t.Fatalf("take from empty tree")
+    }
+}
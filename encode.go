@@ -0,0 +1,106 @@
+package rbt
+
+import (
+    "encoding/binary"
+    "encoding/csv"
+    "fmt"
+    "io"
+)
+
+// Write entries to w as CSV, one record per entry in ascending key order.
+// Each record consists of keyCols(key, value) followed by
+// valueCols(key, value). Returns the first write error encountered.
+func (t *RbMap) WriteCSV(w io.Writer, keyCols, valueCols func(key, value interface{}) []string) error {
+    cw := csv.NewWriter(w)
+    for n := t.First(); n != nil; n = n.Next() {
+        rec := append(keyCols(n.key, n.Value), valueCols(n.key, n.Value)...)
+        if err := cw.Write(rec); err != nil {
+            return err
+        }
+    }
+    cw.Flush()
+    return cw.Error()
+}
+
+// Write entries to w in ascending key order: the number of entries as
+// uvarint, followed by every entry written by enc. The encoding of keys and
+// values is entirely up to enc; see Decode for the reverse operation.
+func (t *RbMap) Encode(w io.Writer, enc func(key, value interface{}, w io.Writer) error) error {
+    var buf [binary.MaxVarintLen64]byte
+    if _, err := w.Write(buf[:binary.PutUvarint(buf[:], uint64(t.size))]); err != nil {
+        return err
+    }
+    for n := t.First(); n != nil; n = n.Next() {
+        if err := enc(n.key, n.Value, w); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// Replace tree contents with entries written by Encode, read from r by dec.
+// Decoded keys must be in strictly ascending order, which lets the tree be
+// built in O(n) without rebalancing. maxCount limits the number of entries,
+// guarding against corrupt input; zero or negative means no limit. On error
+// the tree is left unchanged.
+func (t *RbMap) Decode(r io.Reader, maxCount int, dec func(r io.Reader) (key, value interface{}, err error)) error {
+    br, ok := r.(io.ByteReader)
+    if !ok {
+        br = byteReader{r}
+    }
+    cnt, err := binary.ReadUvarint(br)
+    if err != nil {
+        return err
+    }
+    if maxCount > 0 && cnt > uint64(maxCount) {
+        return fmt.Errorf("rbt: entry count %d exceeds limit %d", cnt, maxCount)
+    }
+    var nodes []*RbMapNode
+    for i := uint64(0); i < cnt; i++ {
+        k, v, err := dec(r)
+        if err != nil {
+            return err
+        }
+        if i > 0 && !t.less(nodes[i-1].key, k) {
+            return ErrNotSorted
+        }
+        nodes = append(nodes, &RbMapNode{key: k, Value: v})
+    }
+    t.Clear()
+    t.build(nodes)
+    if t.order != nil {
+        for _, n := range nodes {
+            t.track(n)
+        }
+    }
+    return nil
+}
+
+// Adapts io.Reader to io.ByteReader without reading ahead.
+type byteReader struct {
+    io.Reader
+}
+
+func (r byteReader) ReadByte() (byte, error) {
+    var b [1]byte
+    _, err := io.ReadFull(r.Reader, b[:])
+    return b[0], err
+}
+
+// Write keys to w in ascending order, in the same format as Encode but
+// without values: the number of keys as uvarint, followed by every key
+// written by enc. Used for compact dumps of sets.
+func (t *RbMap) EncodeKeys(w io.Writer, enc func(key interface{}, w io.Writer) error) error {
+    return t.Encode(w, func(key, _ interface{}, w io.Writer) error {
+        return enc(key, w)
+    })
+}
+
+// Replace tree contents with keys written by EncodeKeys, read from r by
+// dec. All values are nil. See Decode for ordering and maxCount.
+func (t *RbMap) DecodeKeys(r io.Reader, maxCount int, dec func(r io.Reader) (key interface{}, err error)) error {
+    return t.Decode(r, maxCount, func(r io.Reader) (interface{}, interface{}, error) {
+        key, err := dec(r)
+        return key, nil, err
+    })
+}
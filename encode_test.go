@@ -0,0 +1,111 @@
+package rbt
+
+import (
+    "bytes"
+    "encoding/binary"
+    "io"
+    "strconv"
+    "testing"
+)
+
+func TestWriteCSV(t *testing.T) {
+    r := newstrtree("b,x", "a", "c")
+    var buf bytes.Buffer
+    err := r.WriteCSV(&buf,
+        func(k, v interface{}) []string { return []string{k.(string)} },
+        func(k, v interface{}) []string { return []string{strconv.Itoa(v.(int)), "!"} })
+    if err != nil {
+        t.Fatalf("WriteCSV: %v", err)
+    }
+    want := "a,1,!\n\"b,x\",0,!\nc,2,!\n"
+    if buf.String() != want {
+        t.Fatalf("WriteCSV: got %q, want %q", buf.String(), want)
+    }
+}
+
+func encodeInt(k, v interface{}, w io.Writer) error {
+    return binary.Write(w, binary.LittleEndian, [2]int64{int64(k.(int)), int64(v.(int))})
+}
+
+func decodeInt(r io.Reader) (k, v interface{}, err error) {
+    var kv [2]int64
+    err = binary.Read(r, binary.LittleEndian, &kv)
+    return int(kv[0]), int(kv[1]), err
+}
+
+func TestEncodeDecode(t *testing.T) {
+    for _, size := range []int{0, 1, 2, 3, 7, 8, 1000} {
+        r := newtree(t, size)
+        var buf bytes.Buffer
+        if err := r.Encode(&buf, encodeInt); err != nil {
+            t.Fatalf("encode: %v", err)
+        }
+        d := newtree(t, 10)
+        // plain reader, so that uvarint is read byte by byte
+        if err := d.Decode(struct{ io.Reader }{&buf}, 0, decodeInt); err != nil {
+            t.Fatalf("decode: %v", err)
+        }
+        d.verify()
+        if d.Size() != r.Size() {
+            t.Fatalf("decoded size %d, want %d", d.Size(), r.Size())
+        }
+        for a, b := r.First(), d.First(); a != nil; a, b = a.Next(), b.Next() {
+            if a.Key() != b.Key() || a.Value != b.Value {
+                t.Fatalf("decoded entry mismatch")
+            }
+        }
+    }
+}
+
+func TestDecodeErrors(t *testing.T) {
+    var buf bytes.Buffer
+    r := newinttree(1, 2, 3)
+    r.Encode(&buf, encodeInt)
+    data := buf.Bytes()
+    d := newinttree(5)
+    if err := d.Decode(bytes.NewReader(data), 2, decodeInt); err == nil {
+        t.Fatalf("count limit not enforced")
+    }
+    if err := d.Decode(bytes.NewReader(data[:len(data)-1]), 0, decodeInt); err == nil {
+        t.Fatalf("truncated input accepted")
+    }
+    buf.Reset()
+    r.Encode(&buf, func(k, v interface{}, w io.Writer) error {
+        return encodeInt(-k.(int), v, w)
+    })
+    if err := d.Decode(&buf, 0, decodeInt); err != ErrNotSorted {
+        t.Fatalf("unsorted input: got %v", err)
+    }
+    if d.Size() != 1 || d.Find(5) != 5 {
+        t.Fatalf("tree modified by failed decode")
+    }
+}
+
+func TestEncodeDecodeKeys(t *testing.T) {
+    r := newtree(t, 1000)
+    var buf bytes.Buffer
+    err := r.EncodeKeys(&buf, func(k interface{}, w io.Writer) error {
+        return binary.Write(w, binary.LittleEndian, int64(k.(int)))
+    })
+    if err != nil {
+        t.Fatalf("encode keys: %v", err)
+    }
+    if buf.Len() > r.Size()*8+binary.MaxVarintLen64 {
+        t.Fatalf("values encoded")
+    }
+    d := newinttree()
+    err = d.DecodeKeys(&buf, 0, func(r io.Reader) (interface{}, error) {
+        var k int64
+        err := binary.Read(r, binary.LittleEndian, &k)
+        return int(k), err
+    })
+    if err != nil || d.Size() != r.Size() {
+        t.Fatalf("decode keys: %v, size %d", err, d.Size())
+    }
+    for a, b := r.First(), d.First(); a != nil; a, b = a.Next(), b.Next() {
+        if a.Key() != b.Key() || b.Value != nil {
+            t.Fatalf("decoded key mismatch")
+        }
+    }
+    d.verify()
+}
@@ -0,0 +1,65 @@
+package rbt
+
+// Entry is a copy of tree entry key and value. Unlike RbMapNode, it gives
+// no access to the tree structure, so it is safe to pass across API
+// boundaries.
+type Entry struct {
+    Key, Value interface{}
+}
+
+// Returns entry copied from the node.
+func (n *RbMapNode) Entry() Entry {
+    return Entry{n.key, n.Value}
+}
+
+// Find entry by key, ok is false if key not found.
+func (t *RbMap) FindEntry(key interface{}) (Entry, bool) {
+    if n := t.FindNode(key); n != nil {
+        return n.Entry(), true
+    }
+    return Entry{}, false
+}
+
+// Returns entry with the lowest key, ok is false if tree is empty.
+func (t *RbMap) FirstEntry() (Entry, bool) {
+    if n := t.First(); n != nil {
+        return n.Entry(), true
+    }
+    return Entry{}, false
+}
+
+// Returns entry with the highest key, ok is false if tree is empty.
+func (t *RbMap) LastEntry() (Entry, bool) {
+    if n := t.Last(); n != nil {
+        return n.Entry(), true
+    }
+    return Entry{}, false
+}
+
+// Iterate over entries in ascending key order, until f returns false.
+func (t *RbMap) RangeEntries(f func(Entry) bool) {
+    for n := t.First(); n != nil; n = n.Next() {
+        if !f(n.Entry()) {
+            return
+        }
+    }
+}
+
+// Returns all entries in ascending key order.
+func (t *RbMap) Entries() []Entry {
+    e := make([]Entry, 0, t.size)
+    for n := t.First(); n != nil; n = n.Next() {
+        e = append(e, n.Entry())
+    }
+    return e
+}
+
+// Returns copies of entries with keys in [lo, hi), in ascending key order.
+// The tree is not modified.
+func (t *RbMap) CollectRange(lo, hi interface{}) []Entry {
+    var e []Entry
+    for n := t.LowerBound(lo); n != nil && t.less(n.key, hi); n = n.Next() {
+        e = append(e, n.Entry())
+    }
+    return e
+}
@@ -0,0 +1,50 @@
+package rbt
+
+import (
+    "testing"
+)
+
+func TestEntries(t *testing.T) {
+    r := newstrtree("b", "a", "c")
+    if e, ok := r.FindEntry("b"); !ok || e != (Entry{"b", 0}) {
+        t.Fatalf("find entry: %v, %v", e, ok)
+    }
+    if _, ok := r.FindEntry("x"); ok {
+        t.Fatalf("missing entry found")
+    }
+    f, _ := r.FirstEntry()
+    l, _ := r.LastEntry()
+    if f != (Entry{"a", 1}) || l != (Entry{"c", 2}) {
+        t.Fatalf("first/last entry: %v, %v", f, l)
+    }
+    var keys string
+    r.RangeEntries(func(e Entry) bool {
+        keys += e.Key.(string)
+        return e.Key != "b"
+    })
+    if keys != "ab" {
+        t.Fatalf("range entries: got %q", keys)
+    }
+    all := r.Entries()
+    all[0].Value = 100
+    if len(all) != 3 || all[2].Key != "c" || r.Find("a") != 1 {
+        t.Fatalf("entries: got %v", all)
+    }
+    if _, ok := newstrtree().FirstEntry(); ok {
+        t.Fatalf("first entry of empty tree")
+    }
+}
+
+func TestCollectRange(t *testing.T) {
+    r := newstrtree("a", "b", "c", "d", "e")
+    got := r.CollectRange("b", "d")
+    if len(got) != 2 || got[0] != (Entry{"b", 1}) || got[1] != (Entry{"c", 2}) {
+        t.Fatalf("collect [b, d): got %v", got)
+    }
+    if got := r.CollectRange("bb", "z"); len(got) != 3 || got[0].Key != "c" {
+        t.Fatalf("collect [bb, z): got %v", got)
+    }
+    if r.CollectRange("d", "d") != nil || r.CollectRange("x", "z") != nil || r.Size() != 5 {
+        t.Fatalf("collect empty range")
+    }
+}
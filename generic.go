@@ -0,0 +1,382 @@
+package rbt
+
+import (
+    "cmp"
+)
+
+// RbMapG is a generic variant of RbMap with typed keys and values, which
+// spares type assertions in the comparison function and boxing of keys and
+// values. The algorithm is the same as of RbMap. For keys of ordered types,
+// ComparableMap compares keys with < directly.
+// Note: all methods are not goroutine-safe.
+type RbMapG[K any, V any] struct {
+    less func(a, b K) bool
+    root *RbMapNodeG[K, V]
+    size int
+}
+
+// RbMapG tree node, contains key and value. It is safe to overwrite Value
+// in-place.
+type RbMapNodeG[K any, V any] struct {
+    left, right, parent *RbMapNodeG[K, V]
+    key                 K
+    Value               V
+    isred               bool
+}
+
+// Create new RbMapG with provided key comparison function, which must
+// return true if a < b, false otherwise.
+func NewRbMapG[K any, V any](less func(a, b K) bool) *RbMapG[K, V] {
+    return &RbMapG[K, V]{less: less}
+}
+
+// Create new RbMapG for keys of ordered types, compared with cmp.Less.
+// Unlike ComparableMap, comparisons go through the comparison function.
+func NewOrdered[K cmp.Ordered, V any]() *RbMapG[K, V] {
+    return NewRbMapG[K, V](cmp.Less[K])
+}
+
+// Find value by key. Returns zero value and false if key not found.
+func (t *RbMapG[K, V]) Find(key K) (V, bool) {
+    if n := t.FindNode(key); n != nil {
+        return n.Value, true
+    }
+    var zero V
+    return zero, false
+}
+
+// Find a node by key, returns nil if not found.
+func (t *RbMapG[K, V]) FindNode(key K) *RbMapNodeG[K, V] {
+    x := t.root
+    for x != nil {
+        if t.less(x.key, key) {
+            x = x.right
+        } else if t.less(key, x.key) {
+            x = x.left
+        } else {
+            return x
+        }
+    }
+    return nil
+}
+
+// Find the first node with key not less than the given key, returns nil if
+// there is no such node.
+func (t *RbMapG[K, V]) LowerBound(key K) *RbMapNodeG[K, V] {
+    var y *RbMapNodeG[K, V]
+    x := t.root
+    for x != nil {
+        if t.less(x.key, key) {
+            x = x.right
+        } else {
+            y = x
+            x = x.left
+        }
+    }
+    return y
+}
+
+// Find the first node with key greater than the given key, returns nil if
+// there is no such node.
+func (t *RbMapG[K, V]) UpperBound(key K) *RbMapNodeG[K, V] {
+    var y *RbMapNodeG[K, V]
+    x := t.root
+    for x != nil {
+        if t.less(key, x.key) {
+            y = x
+            x = x.left
+        } else {
+            x = x.right
+        }
+    }
+    return y
+}
+
+// Get first node in the tree (with lowest key value).
+func (t *RbMapG[K, V]) First() *RbMapNodeG[K, V] {
+    if t.root == nil {
+        return nil
+    }
+    return t.root.min()
+}
+
+// Get last node in the tree (with highest key value).
+func (t *RbMapG[K, V]) Last() *RbMapNodeG[K, V] {
+    if t.root == nil {
+        return nil
+    }
+    return t.root.max()
+}
+
+// Returns number of entries in the tree.
+func (t *RbMapG[K, V]) Size() int {
+    return t.size
+}
+
+// Iterate over entries in ascending key order, until f returns false.
+func (t *RbMapG[K, V]) Range(f func(key K, value V) bool) {
+    for n := t.First(); n != nil; n = n.Next() {
+        if !f(n.key, n.Value) {
+            return
+        }
+    }
+}
+
+// Remove all entries in the tree.
+func (t *RbMapG[K, V]) Clear() {
+    t.root = nil
+    t.size = 0
+}
+
+// Returns key associated with tree node.
+func (x *RbMapNodeG[K, V]) Key() K {
+    return x.key
+}
+
+// Get next node, in ascending key value order.
+func (x *RbMapNodeG[K, V]) Next() *RbMapNodeG[K, V] {
+    if x.right != nil {
+        return x.right.min()
+    }
+    y := x.parent
+    for y != nil && x == y.right {
+        x = y
+        y = y.parent
+    }
+    return y
+}
+
+// Get previous node, in descending key value order.
+func (x *RbMapNodeG[K, V]) Prev() *RbMapNodeG[K, V] {
+    if x.left != nil {
+        return x.left.max()
+    }
+    y := x.parent
+    for y != nil && x == y.left {
+        x = y
+        y = y.parent
+    }
+    return y
+}
+
+// Insert key and value into the tree. If new entry is created, returns true.
+// If key already exists, value gets replaced and Insert returns false.
+func (t *RbMapG[K, V]) Insert(key K, value V) bool {
+    x := t.root
+    var y *RbMapNodeG[K, V]
+    left := false
+    for x != nil {
+        y = x
+        if t.less(x.key, key) {
+            x, left = x.right, false
+        } else if t.less(key, x.key) {
+            x, left = x.left, true
+        } else {
+            x.Value = value
+            return false
+        }
+    }
+    t.attach(y, left, key, value)
+    return true
+}
+
+// Link new entry as left or right child of parent, or as root if parent is
+// nil, and rebalance the tree.
+func (t *RbMapG[K, V]) attach(parent *RbMapNodeG[K, V], left bool, key K, value V) {
+    z := &RbMapNodeG[K, V]{parent: parent, isred: true, key: key, Value: value}
+    if parent == nil {
+        t.root = z
+    } else if left {
+        parent.left = z
+    } else {
+        parent.right = z
+    }
+    t.insertFixup(z)
+    t.size++
+}
+
+// Delete tree node by key. Returns true if key was found and deleted.
+func (t *RbMapG[K, V]) Delete(key K) bool {
+    if z := t.FindNode(key); z != nil {
+        t.DeleteNode(z)
+        return true
+    }
+    return false
+}
+
+// Delete tree node.
+func (t *RbMapG[K, V]) DeleteNode(n *RbMapNodeG[K, V]) {
+    var x *RbMapNodeG[K, V]
+    if n.left != nil && n.right != nil {
+        x = n.left.max()
+        n.key, n.Value = x.key, x.Value
+        n = x
+    }
+    if n.right == nil {
+        x = n.left
+    } else {
+        x = n.right
+    }
+    if !n.red() {
+        n.isred = x.red()
+        if n.parent != nil {
+            t.deleteFixup(n)
+        }
+    }
+    t.replace(n, x)
+    if t.root.red() {
+        t.root.isred = false
+    }
+    t.size--
+}
+
+func (t *RbMapG[K, V]) deleteFixup(n *RbMapNodeG[K, V]) {
+    var s, p *RbMapNodeG[K, V]
+    for {
+        s, p = n.sibling(), n.parent
+        if s.red() {
+            p.isred, s.isred = true, false
+            if n == p.left {
+                t.rotateLeft(p)
+                s = p.right
+            } else {
+                t.rotateRight(p)
+                s = p.left
+            }
+        }
+        if !p.red() && !s.red() && !s.left.red() && !s.right.red() {
+            s.isred = true
+            if p.parent != nil {
+                n = p
+                continue
+            }
+            return
+        }
+        break
+    }
+    if n.parent.red() && !s.red() && !s.left.red() && !s.right.red() {
+        s.isred, n.parent.isred = true, false
+        return
+    }
+    if !s.red() {
+        if n == n.parent.left && s.left.red() && !s.right.red() {
+            s.isred, s.left.isred = true, false
+            t.rotateRight(s)
+            s = n.parent.right
+        } else if n == n.parent.right && s.right.red() && !s.left.red() {
+            s.isred, s.right.isred = true, false
+            t.rotateLeft(s)
+            s = n.parent.left
+        }
+    }
+    s.isred = n.parent.isred
+    n.parent.isred = false
+    if n == n.parent.left {
+        s.right.isred = false
+        t.rotateLeft(n.parent)
+    } else {
+        s.left.isred = false
+        t.rotateRight(n.parent)
+    }
+}
+
+func (t *RbMapG[K, V]) insertFixup(x *RbMapNodeG[K, V]) {
+    for x.parent.red() {
+        g := x.parent.parent
+        if x.parent == g.left {
+            if y := g.right; y.red() {
+                x.parent.isred, y.isred, g.isred = false, false, true
+                x = g
+                continue
+            }
+            if x == x.parent.right {
+                x = x.parent
+                t.rotateLeft(x)
+            }
+            x.parent.isred, x.parent.parent.isred = false, true
+            t.rotateRight(x.parent.parent)
+        } else {
+            if y := g.left; y.red() {
+                x.parent.isred, y.isred, g.isred = false, false, true
+                x = g
+                continue
+            }
+            if x == x.parent.left {
+                x = x.parent
+                t.rotateRight(x)
+            }
+            x.parent.isred, x.parent.parent.isred = false, true
+            t.rotateLeft(x.parent.parent)
+        }
+    }
+    t.root.isred = false
+}
+
+func (t *RbMapG[K, V]) rotateLeft(n *RbMapNodeG[K, V]) {
+    r := n.right
+    t.replace(n, r)
+    n.right = r.left
+    if r.left != nil {
+        r.left.parent = n
+    }
+    r.left, n.parent = n, r
+}
+
+func (t *RbMapG[K, V]) rotateRight(n *RbMapNodeG[K, V]) {
+    l := n.left
+    t.replace(n, l)
+    n.left = l.right
+    if l.right != nil {
+        l.right.parent = n
+    }
+    l.right, n.parent = n, l
+}
+
+func (t *RbMapG[K, V]) replace(u, v *RbMapNodeG[K, V]) {
+    parent := u.parent
+    if parent == nil {
+        t.root = v
+    } else if u == parent.left {
+        parent.left = v
+    } else {
+        parent.right = v
+    }
+    if v != nil {
+        v.parent = parent
+    }
+}
+
+func (n *RbMapNodeG[K, V]) sibling() *RbMapNodeG[K, V] {
+    if n == n.parent.left {
+        return n.parent.right
+    }
+    return n.parent.left
+}
+
+func (n *RbMapNodeG[K, V]) min() *RbMapNodeG[K, V] {
+    for n.left != nil {
+        n = n.left
+    }
+    return n
+}
+
+func (n *RbMapNodeG[K, V]) max() *RbMapNodeG[K, V] {
+    for n.right != nil {
+        n = n.right
+    }
+    return n
+}
+
+// Nil-safe color check, nil leaves are black.
+func (n *RbMapNodeG[K, V]) red() bool {
+    return n != nil && n.isred
+}
+
+// Returns results of f applied to every entry, in ascending key order.
+func MapEntries[K, V, R any](t *RbMapG[K, V], f func(K, V) R) []R {
+    r := make([]R, 0, t.size)
+    for n := t.First(); n != nil; n = n.Next() {
+        r = append(r, f(n.key, n.Value))
+    }
+    return r
+}
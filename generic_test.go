@@ -0,0 +1,147 @@
+package rbt
+
+import (
+    "math/rand"
+    "strconv"
+    "testing"
+)
+
+// Internal consistency check, same as RbMap.verify.
+func (t *RbMapG[K, V]) verify() {
+    var check func(n *RbMapNodeG[K, V]) int
+    check = func(n *RbMapNodeG[K, V]) int {
+        if n == nil {
+            return 1
+        }
+        if n.red() && (n.left.red() || n.right.red()) {
+            panic("red node has red child")
+        }
+        if (n.left != nil && n.left.parent != n) || (n.right != nil && n.right.parent != n) {
+            panic("parent link")
+        }
+        l, r := check(n.left), check(n.right)
+        if l != r {
+            panic("black count")
+        }
+        if !n.red() {
+            l++
+        }
+        return l
+    }
+    if t.root.red() {
+        panic("root is red")
+    }
+    check(t.root)
+}
+
+func TestRbMapG(t *testing.T) {
+    // descending order, to make sure the comparison function is used
+    r := NewRbMapG[int, string](func(a, b int) bool { return a > b })
+    ref := make(map[int]string)
+    for i := 0; i < 100000; i++ {
+        k := rand.Intn(5000)
+        _, exists := ref[k]
+        if rand.Intn(3) == 0 {
+            if r.Delete(k) != exists {
+                t.Fatalf("delete %d: wrong result", k)
+            }
+            delete(ref, k)
+        } else {
+            if r.Insert(k, strconv.Itoa(k)) == exists {
+                t.Fatalf("insert %d: wrong result", k)
+            }
+            ref[k] = strconv.Itoa(k)
+        }
+        if i%10000 == 0 {
+            r.verify()
+        }
+    }
+    r.verify()
+    if r.Size() != len(ref) {
+        t.Fatalf("size %d, want %d", r.Size(), len(ref))
+    }
+    prev := 1 << 30
+    for n := r.First(); n != nil; n = n.Next() {
+        if n.Key() >= prev {
+            t.Fatalf("keys out of order")
+        }
+        if v, ok := r.Find(n.Key()); !ok || v != ref[n.Key()] {
+            t.Fatalf("key %d: got %q", n.Key(), v)
+        }
+        prev = n.Key()
+    }
+}
+
+func TestMapEntries(t *testing.T) {
+    r := NewRbMapG[string, int](func(a, b string) bool { return a < b })
+    r.Insert("b", 2)
+    r.Insert("a", 1)
+    r.Insert("c", 3)
+    got := MapEntries(r, func(k string, v int) string { return k + strconv.Itoa(v) })
+    if len(got) != 3 || got[0] != "a1" || got[1] != "b2" || got[2] != "c3" {
+        t.Fatalf("map entries: got %v", got)
+    }
+    if len(MapEntries(NewRbMapG[string, int](nil), func(k string, v int) int { return v })) != 0 {
+        t.Fatalf("map entries of empty tree")
+    }
+}
+
+func TestNewOrdered(t *testing.T) {
+    r := NewOrdered[string, int]()
+    for i, k := range []string{"d", "b", "a", "c", "e"} {
+        r.Insert(k, i)
+    }
+    r.verify()
+    var got string
+    r.Range(func(k string, v int) bool {
+        got += k
+        return k != "d"
+    })
+    if got != "abcd" {
+        t.Fatalf("range: got %q", got)
+    }
+    if n := r.LowerBound("bb"); n == nil || n.Key() != "c" {
+        t.Fatalf("lower bound: got %v", n)
+    }
+    if n := r.UpperBound("c"); n == nil || n.Key() != "d" || r.UpperBound("e") != nil {
+        t.Fatalf("upper bound: got %v", n)
+    }
+}
+
+func BenchmarkFindInterface(b *testing.B) {
+    r := NewRbMap(func(k1, k2 interface{}) bool {
+        return k1.(int) < k2.(int)
+    })
+    for i := 0; i < 100000; i++ {
+        r.Insert(i, i)
+    }
+    b.ReportAllocs()
+    b.ResetTimer()
+    for i := 0; i < b.N; i++ {
+        _ = r.Find(i % 100000).(int)
+    }
+}
+
+func BenchmarkFindGeneric(b *testing.B) {
+    r := NewOrdered[int, int]()
+    for i := 0; i < 100000; i++ {
+        r.Insert(i, i)
+    }
+    b.ReportAllocs()
+    b.ResetTimer()
+    for i := 0; i < b.N; i++ {
+        r.Find(i % 100000)
+    }
+}
+
+func BenchmarkFindComparable(b *testing.B) {
+    r := NewComparableMap[int, int]()
+    for i := 0; i < 100000; i++ {
+        r.Insert(i, i)
+    }
+    b.ReportAllocs()
+    b.ResetTimer()
+    for i := 0; i < b.N; i++ {
+        r.Find(i % 100000)
+    }
+}
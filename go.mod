@@ -0,0 +1,3 @@
+module github.com/pantonov/rbt
+
+go 1.23
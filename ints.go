@@ -0,0 +1,100 @@
+package rbt
+
+// Helpers for trees with int keys, ordered by k1.(int) < k2.(int).
+
+// Iterate over runs of integers missing between the smallest and the
+// largest key, in ascending order, until f returns false. Each run is
+// reported as inclusive [start, end] range. Keys must be ints.
+func (t *RbMap) MissingInts(f func(start, end int) bool) {
+    t.RangePairs(func(a, _, b, _ interface{}) bool {
+        if lo, hi := a.(int)+1, b.(int)-1; lo <= hi {
+            return f(lo, hi)
+        }
+        return true
+    })
+}
+
+// Count keys in fixed-width buckets [b, b+bucketWidth), where b is a
+// multiple of bucketWidth. Buckets containing at least one key are reported
+// in ascending order, until f returns false. Keys must be ints. Panics if
+// bucketWidth is less than 1.
+func (t *RbMap) BucketCounts(bucketWidth int, f func(bucketStart int, count int) bool) {
+    if bucketWidth < 1 {
+        panic("rbt: bucket width must be positive")
+    }
+    start, count := 0, 0
+    for n := t.First(); n != nil; n = n.Next() {
+        k := n.key.(int)
+        b := k - k%bucketWidth
+        if k%bucketWidth < 0 {
+            b -= bucketWidth
+        }
+        if count > 0 && b != start {
+            if !f(start, count) {
+                return
+            }
+            count = 0
+        }
+        start = b
+        count++
+    }
+    if count > 0 {
+        f(start, count)
+    }
+}
+
+// Renumber keys to 0..Size()-1 preserving their order and values, so that
+// the i-th smallest key becomes i. Returns mapping from old keys to new
+// ones. Since the order of keys is unchanged, keys are replaced in place,
+// in O(n). New keys are ints, so the comparison function must order ints.
+func (t *RbMap) CompactIntKeys() map[interface{}]int {
+    m := make(map[interface{}]int, t.size)
+    i := 0
+    for n := t.First(); n != nil; n = n.Next() {
+        m[n.key] = i
+        n.key = i
+        i++
+    }
+    if t.order != nil {
+        for o := t.order.First(); o != nil; o = o.Next() {
+            o.Value = m[o.Value]
+        }
+    }
+    return m
+}
+
+// Find the first run of n consecutive integers in [lo, hi) which are not
+// keys of the tree, treating keys as used ids. Returns start of the run, ok
+// is false if there is no such run. Keys must be ints.
+func (t *RbMap) FindFreeRange(lo, hi, n int) (start int, ok bool) {
+    start = lo
+    for x := t.LowerBound(lo); x != nil && x.key.(int) < hi; x = x.Next() {
+        if x.key.(int)-start >= n {
+            return start, true
+        }
+        start = x.key.(int) + 1
+    }
+    if hi-start >= n {
+        return start, true
+    }
+    return 0, false
+}
+
+// Export key membership as a bitmap, where bit i of word i/64 is set if key
+// base+i is present. Keys must be ints, not less than base; the bitmap is
+// sized to cover the largest key. Returns nil for empty tree.
+func (t *RbMap) ToBitmap(base int) []uint64 {
+    last := t.Last()
+    if last == nil {
+        return nil
+    }
+    if t.First().key.(int) < base {
+        panic("rbt: key is below bitmap base")
+    }
+    bm := make([]uint64, (last.key.(int)-base)/64+1)
+    for n := t.First(); n != nil; n = n.Next() {
+        i := n.key.(int) - base
+        bm[i/64] |= 1 << uint(i%64)
+    }
+    return bm
+}
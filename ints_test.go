@@ -0,0 +1,124 @@
+package rbt
+
+import (
+    "fmt"
+    "testing"
+)
+
+func newinttree(keys ...int) *RbMap {
+    r := NewRbMap(func(k1, k2 interface{}) bool {
+        return k1.(int) < k2.(int)
+    })
+    for _, k := range keys {
+        r.Insert(k, k)
+    }
+    return r
+}
+
+func TestMissingInts(t *testing.T) {
+    r := newinttree(1, 2, 5, 7, 8, 12)
+    var got string
+    r.MissingInts(func(s, e int) bool {
+        got += fmt.Sprintf("[%d,%d]", s, e)
+        return true
+    })
+    if got != "[3,4][6,6][9,11]" {
+        t.Fatalf("missing ints: got %s", got)
+    }
+    got = ""
+    r.MissingInts(func(s, e int) bool {
+        got += fmt.Sprintf("[%d,%d]", s, e)
+        return false
+    })
+    if got != "[3,4]" {
+        t.Fatalf("missing ints early stop: got %s", got)
+    }
+}
+
+func TestBucketCounts(t *testing.T) {
+    r := newinttree(-11, -10, -1, 0, 3, 9, 10, 35)
+    var got string
+    r.BucketCounts(10, func(b, c int) bool {
+        got += fmt.Sprintf("%d:%d ", b, c)
+        return true
+    })
+    if got != "-20:1 -10:2 0:3 10:1 30:1 " {
+        t.Fatalf("bucket counts: got %q", got)
+    }
+    got = ""
+    r.BucketCounts(10, func(b, c int) bool {
+        got += fmt.Sprintf("%d:%d ", b, c)
+        return b < -10
+    })
+    if got != "-20:1 -10:2 " {
+        t.Fatalf("bucket counts early stop: got %q", got)
+    }
+}
+
+func TestCompactIntKeys(t *testing.T) {
+    r := newinttree(40, 10, 30, 20)
+    r.TrackInsertionOrder()
+    r.Insert(5, 5)
+    m := r.CompactIntKeys()
+    if len(m) != 5 || m[5] != 0 || m[10] != 1 || m[40] != 4 {
+        t.Fatalf("compact mapping: got %v", m)
+    }
+    for i := 0; i < 5; i++ {
+        if r.FindNode(i) == nil {
+            t.Fatalf("key %d not found", i)
+        }
+    }
+    if r.Find(0) != 5 || r.Find(4) != 40 {
+        t.Fatalf("values not preserved")
+    }
+    var order []int
+    r.RangeByInsertion(func(k, v interface{}) bool {
+        order = append(order, k.(int))
+        return true
+    })
+    if len(order) != 5 || order[4] != 0 {
+        t.Fatalf("insertion order: got %v", order)
+    }
+    r.verify()
+}
+
+func TestFindFreeRange(t *testing.T) {
+    r := newinttree(0, 1, 2, 5, 6, 9, 15)
+    for _, tc := range []struct {
+        lo, hi, n, start int
+        ok               bool
+    }{
+        {0, 20, 1, 3, true},
+        {0, 20, 2, 3, true},
+        {0, 20, 3, 10, true},
+        {0, 20, 5, 10, true},
+        {0, 20, 6, 0, false},
+        {0, 14, 4, 10, true},
+        {0, 13, 4, 0, false},
+        {6, 9, 2, 7, true},
+        {16, 18, 2, 16, true},
+        {-5, 0, 5, -5, true},
+    } {
+        start, ok := r.FindFreeRange(tc.lo, tc.hi, tc.n)
+        if ok != tc.ok || (ok && start != tc.start) {
+            t.Fatalf("free range %d in [%d,%d): got %d, %v", tc.n, tc.lo, tc.hi, start, ok)
+        }
+    }
+}
+
+func TestToBitmap(t *testing.T) {
+    r := newinttree(10, 11, 13, 73, 200)
+    bm := r.ToBitmap(10)
+    if len(bm) != 3 {
+        t.Fatalf("bitmap length: got %d", len(bm))
+    }
+    if bm[0] != 1|2|8|1<<63 || bm[1] != 0 || bm[2] != 1<<62 {
+        t.Fatalf("bitmap: got %x", bm)
+    }
+    if bm := newinttree(0, 63).ToBitmap(0); len(bm) != 1 || bm[0] != 1|1<<63 {
+        t.Fatalf("bitmap of 0, 63: got %x", bm)
+    }
+    if newinttree().ToBitmap(0) != nil {
+        t.Fatalf("bitmap of empty tree")
+    }
+}
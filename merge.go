@@ -0,0 +1,240 @@
+package rbt
+
+import (
+    "container/heap"
+)
+
+// Merge entries of other into t. For keys present in both trees, value in t
+// is set to combine(tValue, otherValue); the result is always stored, even
+// if it is nil. Keys missing in t are inserted with other's value. Both trees
+// must use the same ordering. Matching keys are found by a single merge walk
+// over both trees, so the cost is O(n+m) plus O(log n) per inserted key.
+// other is not modified.
+func (t *RbMap) MergeWith(other *RbMap, combine func(aValue, bValue interface{}) interface{}) {
+    n := t.First()
+    for o := other.First(); o != nil; o = o.Next() {
+        for n != nil && t.less(n.key, o.key) {
+            n = n.Next()
+        }
+        if n != nil && !t.less(o.key, n.key) {
+            n.Value = combine(n.Value, o.Value)
+        } else {
+            // insertion does not move existing entries, so n stays valid
+            t.Insert(o.key, o.Value)
+        }
+    }
+}
+
+// Merge entries of other into t. For keys present in both trees, value from
+// other replaces value in t if overwrite is true, otherwise value in t is
+// kept. Unlike MergeWith, t is relinked from scratch: both trees are walked
+// in order once, and nodes of t together with new nodes for keys missing in
+// t are linked into a balanced tree, so the total cost is O(n+m) regardless
+// of how many keys are inserted. Existing nodes of t are reused, so pointers
+// to them stay valid. Both trees must use the same ordering. other is not
+// modified.
+func (t *RbMap) Merge(other *RbMap, overwrite bool) {
+    nodes := make([]*RbMapNode, 0, t.size+other.size)
+    n, o := t.First(), other.First()
+    for n != nil || o != nil {
+        if o == nil || (n != nil && t.less(n.key, o.key)) {
+            nodes = append(nodes, n)
+            n = n.Next()
+        } else if n == nil || t.less(o.key, n.key) {
+            x := &RbMapNode{key: o.key, Value: o.Value}
+            if t.order != nil {
+                t.track(x)
+            }
+            nodes = append(nodes, x)
+            o = o.Next()
+        } else {
+            if overwrite {
+                n.Value = o.Value
+            }
+            nodes = append(nodes, n)
+            n, o = n.Next(), o.Next()
+        }
+    }
+    t.build(nodes)
+}
+
+// Replace values of t through a lookup table: for every entry whose value is
+// a key in table, the value is replaced with the corresponding value from
+// table. Other entries are left unchanged. Costs O(n log m).
+func (t *RbMap) RemapValues(table *RbMap) {
+    for n := t.First(); n != nil; n = n.Next() {
+        if m := table.FindNode(n.Value); m != nil {
+            n.Value = m.Value
+        }
+    }
+}
+
+// Iterate over entries of several trees in global ascending key order, as
+// if they were merged, until f returns false. Trees are not modified and no
+// merged tree is built: this is a K-way merge of in-order walks, costing
+// O(log k) per visited entry. All trees must be ordered consistently with
+// less. If a key is present in several trees, all its entries are visited,
+// in the order the trees were passed.
+func MergedRange(less LessFunc, f func(key, value interface{}) bool, trees ...*RbMap) {
+    h := &mergeHeap{less: less}
+    for i, t := range trees {
+        if n := t.First(); n != nil {
+            h.items = append(h.items, mergeItem{n, i})
+        }
+    }
+    heap.Init(h)
+    for len(h.items) > 0 {
+        top := &h.items[0]
+        if !f(top.node.key, top.node.Value) {
+            return
+        }
+        if top.node = top.node.Next(); top.node != nil {
+            heap.Fix(h, 0)
+        } else {
+            heap.Pop(h)
+        }
+    }
+}
+
+type mergeItem struct {
+    node *RbMapNode
+    tree int
+}
+
+// Min-heap of tree positions, ordered by key, then by tree index.
+type mergeHeap struct {
+    less  LessFunc
+    items []mergeItem
+}
+
+func (h *mergeHeap) Len() int { return len(h.items) }
+
+func (h *mergeHeap) Less(i, j int) bool {
+    a, b := h.items[i], h.items[j]
+    if h.less(a.node.key, b.node.key) {
+        return true
+    }
+    return !h.less(b.node.key, a.node.key) && a.tree < b.tree
+}
+
+func (h *mergeHeap) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+
+func (h *mergeHeap) Push(x interface{}) { h.items = append(h.items, x.(mergeItem)) }
+
+func (h *mergeHeap) Pop() interface{} {
+    x := h.items[len(h.items)-1]
+    h.items = h.items[:len(h.items)-1]
+    return x
+}
+
+// Apply updates from delta to t. For every entry of delta whose key exists
+// in t, value in t is replaced with delta's value. For keys missing in t,
+// onMissing(key, value) decides whether the entry is inserted; nil onMissing
+// skips all missing keys. Costs O(m log n), which suits deltas much smaller
+// than t. delta is not modified.
+func (t *RbMap) ApplyDelta(delta *RbMap, onMissing func(key, value interface{}) bool) {
+    for d := delta.First(); d != nil; d = d.Next() {
+        if n := t.FindNode(d.key); n != nil {
+            n.Value = d.Value
+        } else if onMissing != nil && onMissing(d.key, d.Value) {
+            t.Insert(d.key, d.Value)
+        }
+    }
+}
+
+// Returns true if t and other have no keys in common. Both trees must use
+// the same ordering. Implemented as a merge walk stopping at the first common
+// key, O(n+m) in the worst case.
+func (t *RbMap) IsDisjoint(other *RbMap) bool {
+    a, b := t.First(), other.First()
+    for a != nil && b != nil {
+        if t.less(a.key, b.key) {
+            a = a.Next()
+        } else if t.less(b.key, a.key) {
+            b = b.Next()
+        } else {
+            return false
+        }
+    }
+    return true
+}
+
+// Sum several key -> int count maps into a new tree of totals, ordered by
+// less. Maps are K-way merged and the result is built in O(n) from the
+// merged sorted stream. All values must be ints; other values cause a
+// panic.
+func SumCounts(less LessFunc, maps ...*RbMap) *RbMap {
+    var nodes []*RbMapNode
+    MergedRange(less, func(key, value interface{}) bool {
+        if l := len(nodes) - 1; l >= 0 && !less(nodes[l].key, key) {
+            nodes[l].Value = nodes[l].Value.(int) + value.(int)
+        } else {
+            nodes = append(nodes, &RbMapNode{key: key, Value: value.(int)})
+        }
+        return true
+    }, maps...)
+    r := NewRbMap(less)
+    r.build(nodes)
+    return r
+}
+
+// Returns number of keys present in exactly one of t and other, computed
+// by a single merge walk in O(n+m) without allocations. Both trees must use
+// the same ordering.
+func (t *RbMap) SymmetricDifferenceCount(other *RbMap) int {
+    cnt := 0
+    a, b := t.First(), other.First()
+    for a != nil && b != nil {
+        if t.less(a.key, b.key) {
+            a = a.Next()
+            cnt++
+        } else if t.less(b.key, a.key) {
+            b = b.Next()
+            cnt++
+        } else {
+            a, b = a.Next(), b.Next()
+        }
+    }
+    for ; a != nil; a = a.Next() {
+        cnt++
+    }
+    for ; b != nil; b = b.Next() {
+        cnt++
+    }
+    return cnt
+}
+
+// Merge-walks t and other and returns the smallest key where they differ,
+// with kind "only-in-a" (key is only in t), "only-in-b" (key is only in
+// other) or "value-differs" (valueEq returned false). Returns ok=false if
+// trees are identical. Both trees must use the same ordering.
+func (t *RbMap) FirstDifference(other *RbMap, valueEq func(a, b interface{}) bool) (key interface{}, kind string, ok bool) {
+    a, b := t.First(), other.First()
+    for a != nil && b != nil {
+        if t.less(a.key, b.key) {
+            return a.key, "only-in-a", true
+        } else if t.less(b.key, a.key) {
+            return b.key, "only-in-b", true
+        } else if !valueEq(a.Value, b.Value) {
+            return a.key, "value-differs", true
+        }
+        a, b = a.Next(), b.Next()
+    }
+    if a != nil {
+        return a.key, "only-in-a", true
+    }
+    if b != nil {
+        return b.key, "only-in-b", true
+    }
+    return nil, "", false
+}
+
+// Returns node of t with the smallest key greater than all keys of other,
+// or nil if there is none. If other is empty, returns t.First().
+func (t *RbMap) FirstAfter(other *RbMap) *RbMapNode {
+    last := other.Last()
+    if last == nil {
+        return t.First()
+    }
+    return t.UpperBound(last.key)
+}
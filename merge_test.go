@@ -0,0 +1,200 @@
+package rbt
+
+import (
+    "strconv"
+    "testing"
+)
+
+func TestMergeWith(t *testing.T) {
+    a := newstrtree("a", "c", "e")      // a:0 c:1 e:2
+    b := newstrtree("b", "c", "f", "a") // b:0 c:1 f:2 a:3
+    a.MergeWith(b, func(x, y interface{}) interface{} { return x.(int)*10 + y.(int) })
+    want := map[string]int{"a": 3, "b": 0, "c": 11, "e": 2, "f": 2}
+    if a.Size() != len(want) {
+        t.Fatalf("merged size %d, want %d", a.Size(), len(want))
+    }
+    for k, v := range want {
+        if a.Find(k) != v {
+            t.Fatalf("key %s: got %v, want %d", k, a.Find(k), v)
+        }
+    }
+    if b.Size() != 4 || b.Find("c") != 1 {
+        t.Fatalf("source tree modified")
+    }
+    a.verify()
+}
+
+func TestMerge(t *testing.T) {
+    for _, overwrite := range []bool{false, true} {
+        a := newstrtree("a", "c", "e")      // a:0 c:1 e:2
+        b := newstrtree("b", "c", "f", "a") // b:0 c:1 f:2 a:3
+        a.TrackInsertionOrder()
+        e := a.FindNode("e")
+        a.Merge(b, overwrite)
+        a.verify()
+        want := map[string]int{"a": 0, "b": 0, "c": 1, "e": 2, "f": 2}
+        if overwrite {
+            want["a"] = 3
+        }
+        if a.Size() != len(want) || a.FindNode("e") != e {
+            t.Fatalf("merged size %d, want %d", a.Size(), len(want))
+        }
+        for k, v := range want {
+            if a.Find(k) != v {
+                t.Fatalf("overwrite %v, key %s: got %v, want %d", overwrite, k, a.Find(k), v)
+            }
+        }
+        var order string
+        a.RangeByInsertion(func(k, v interface{}) bool {
+            order += k.(string)
+            return true
+        })
+        if order != "acebf" {
+            t.Fatalf("insertion order: got %q", order)
+        }
+        if b.Size() != 4 || b.Find("a") != 3 {
+            t.Fatalf("source tree modified")
+        }
+    }
+    a := newstrtree("a")
+    a.Merge(newstrtree(), true)
+    newstrtree().Merge(a, false)
+    if a.Size() != 1 {
+        t.Fatalf("merge with empty tree")
+    }
+}
+
+func TestRemapValues(t *testing.T) {
+    r := newstrtree("a", "b", "c") // a:0 b:1 c:2
+    table := NewRbMap(func(k1, k2 interface{}) bool {
+        return k1.(int) < k2.(int)
+    })
+    table.Insert(0, 100)
+    table.Insert(2, 200)
+    r.RemapValues(table)
+    if r.Find("a") != 100 || r.Find("b") != 1 || r.Find("c") != 200 {
+        t.Fatalf("remap: got a=%v b=%v c=%v", r.Find("a"), r.Find("b"), r.Find("c"))
+    }
+}
+
+func TestMergedRange(t *testing.T) {
+    less := func(k1, k2 interface{}) bool { return k1.(string) < k2.(string) }
+    a := newstrtree("b", "d", "f")
+    b := newstrtree("a", "g", "d")
+    c := newstrtree()
+    var got string
+    MergedRange(less, func(k, v interface{}) bool {
+        got += k.(string) + strconv.Itoa(v.(int))
+        return true
+    }, a, c, b)
+    if got != "a0b0d1d2f2g1" {
+        t.Fatalf("merged range: got %q", got)
+    }
+    got = ""
+    MergedRange(less, func(k, v interface{}) bool {
+        got += k.(string)
+        return len(got) < 3
+    }, b, a)
+    if got != "abd" {
+        t.Fatalf("merged range early stop: got %q", got)
+    }
+}
+
+func TestApplyDelta(t *testing.T) {
+    r := newstrtree("a", "b", "c")
+    d := newstrtree("b", "x", "y") // b:0 x:1 y:2
+    r.ApplyDelta(d, func(k, v interface{}) bool { return k.(string) == "y" })
+    if r.Size() != 4 || r.Find("b") != 0 || r.Find("y") != 2 || r.Find("x") != nil {
+        t.Fatalf("apply delta: size %d, b=%v y=%v", r.Size(), r.Find("b"), r.Find("y"))
+    }
+    r.ApplyDelta(newstrtree("z"), nil)
+    if r.Size() != 4 {
+        t.Fatalf("missing key inserted with nil onMissing")
+    }
+}
+
+func TestIsDisjoint(t *testing.T) {
+    a := newstrtree("a", "c", "e")
+    if !a.IsDisjoint(newstrtree("b", "d", "f")) || !a.IsDisjoint(newstrtree()) {
+        t.Fatalf("disjoint trees reported as overlapping")
+    }
+    if a.IsDisjoint(newstrtree("b", "e")) || a.IsDisjoint(a) {
+        t.Fatalf("overlapping trees reported as disjoint")
+    }
+}
+
+func TestSumCounts(t *testing.T) {
+    less := func(k1, k2 interface{}) bool { return k1.(string) < k2.(string) }
+    a := newstrtree("a", "b", "c") // a:0 b:1 c:2
+    b := newstrtree("c", "d")      // c:0 d:1
+    c := newstrtree("x", "c")      // x:0 c:1
+    s := SumCounts(less, a, b, c)
+    s.verify()
+    want := map[string]int{"a": 0, "b": 1, "c": 3, "d": 1, "x": 0}
+    if s.Size() != len(want) {
+        t.Fatalf("totals size %d", s.Size())
+    }
+    for k, v := range want {
+        if s.Find(k) != v {
+            t.Fatalf("total for %s: got %v, want %d", k, s.Find(k), v)
+        }
+    }
+    if SumCounts(less).Size() != 0 {
+        t.Fatalf("sum of no maps")
+    }
+    defer func() {
+        if recover() == nil {
+            t.Fatalf("non-int value accepted")
+        }
+    }()
+    a.Insert("a", "x")
+    SumCounts(less, a)
+}
+
+func TestSymmetricDifferenceCount(t *testing.T) {
+    a := newstrtree("a", "b", "d", "f", "g")
+    b := newstrtree("b", "c", "d", "h")
+    if n := a.SymmetricDifferenceCount(b); n != 5 {
+        t.Fatalf("a^b: got %d", n)
+    }
+    if n := b.SymmetricDifferenceCount(newstrtree()); n != 4 {
+        t.Fatalf("b^empty: got %d", n)
+    }
+    if n := a.SymmetricDifferenceCount(a); n != 0 {
+        t.Fatalf("a^a: got %d", n)
+    }
+}
+
+func TestFirstDifference(t *testing.T) {
+    eq := func(a, b interface{}) bool { return a == b }
+    check := func(a, b *RbMap, key interface{}, kind string, ok bool) {
+        k, d, o := a.FirstDifference(b, eq)
+        if k != key || d != kind || o != ok {
+            t.Fatalf("got (%v, %q, %v), want (%v, %q, %v)", k, d, o, key, kind, ok)
+        }
+    }
+    check(newstrtree("a", "b", "c"), newstrtree("a", "b", "c"), nil, "", false)
+    check(newstrtree(), newstrtree(), nil, "", false)
+    check(newstrtree("a", "b", "d"), newstrtree("a", "c", "d"), "b", "only-in-a", true)
+    check(newstrtree("a", "c"), newstrtree("a", "b", "c"), "b", "only-in-b", true)
+    check(newstrtree("a", "b", "c"), newstrtree("a", "b"), "c", "only-in-a", true)
+    check(newstrtree("a"), newstrtree("a", "b"), "b", "only-in-b", true)
+    // same keys, values differ by insertion index
+    check(newstrtree("a", "b", "c"), newstrtree("a", "c", "b"), "b", "value-differs", true)
+}
+
+func TestFirstAfter(t *testing.T) {
+    r := newstrtree("a", "c", "e", "g")
+    if n := r.FirstAfter(newstrtree("b", "c")); n == nil || n.Key() != "e" {
+        t.Fatalf("first after [b c]: got %v", n)
+    }
+    if n := r.FirstAfter(newstrtree("d")); n == nil || n.Key() != "e" {
+        t.Fatalf("first after [d]: got %v", n)
+    }
+    if n := r.FirstAfter(newstrtree()); n == nil || n.Key() != "a" {
+        t.Fatalf("first after empty: got %v", n)
+    }
+    if r.FirstAfter(newstrtree("g")) != nil || newstrtree().FirstAfter(r) != nil {
+        t.Fatalf("first after last key")
+    }
+}
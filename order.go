@@ -0,0 +1,39 @@
+package rbt
+
+// Start tracking insertion order of entries, which makes RangeByInsertion
+// available. Entries already in the tree are considered inserted in
+// ascending key order. Tracking costs an extra O(log n) per insert and
+// delete, as the order is kept in a second tree keyed by insertion sequence.
+// Overwriting value of an existing key does not change its position.
+func (t *RbMap) TrackInsertionOrder() {
+    if t.order != nil {
+        return
+    }
+    t.order = NewRbMap(func(k1, k2 interface{}) bool {
+        return k1.(uint64) < k2.(uint64)
+    })
+    for n := t.First(); n != nil; n = n.Next() {
+        t.track(n)
+    }
+}
+
+// Assign next insertion sequence to the node and record it in order index.
+func (t *RbMap) track(n *RbMapNode) {
+    t.seq++
+    n.seq = t.seq
+    t.order.Insert(n.seq, n.key)
+}
+
+// Iterate over entries in the order they were inserted, until f returns
+// false. Panics if insertion order is not tracked (see TrackInsertionOrder).
+func (t *RbMap) RangeByInsertion(f func(key, value interface{}) bool) {
+    if t.order == nil {
+        panic("rbt: insertion order is not tracked")
+    }
+    for o := t.order.First(); o != nil; o = o.Next() {
+        n := t.FindNode(o.Value)
+        if !f(n.key, n.Value) {
+            return
+        }
+    }
+}
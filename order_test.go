@@ -0,0 +1,38 @@
+package rbt
+
+import (
+    "testing"
+)
+
+func TestRangeByInsertion(t *testing.T) {
+    r := newstrtree("m", "c")
+    r.TrackInsertionOrder()
+    for _, k := range []string{"x", "a", "q", "b"} {
+        r.Insert(k, 0)
+    }
+    r.Insert("c", 1) // overwrite keeps position
+    r.Delete("m")    // node with two children
+    r.Delete("q")
+    r.Insert("m", 2)
+    var got string
+    r.RangeByInsertion(func(k, v interface{}) bool {
+        got += k.(string)
+        return true
+    })
+    if got != "cxabm" {
+        t.Fatalf("insertion order: got %q", got)
+    }
+    rt := newtree(t, 10000)
+    rt.TrackInsertionOrder()
+    for n := rt.First(); n != nil; n = rt.First() {
+        rt.DeleteNode(n)
+        if rt.order.Size() != rt.Size() {
+            t.Fatalf("order index size %d, tree size %d", rt.order.Size(), rt.Size())
+        }
+    }
+    r.Clear()
+    r.RangeByInsertion(func(k, v interface{}) bool {
+        t.Fatalf("cleared tree visited %v", k)
+        return false
+    })
+}
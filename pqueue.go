@@ -0,0 +1,90 @@
+package rbt
+
+// PQueue is a priority queue built on RbMap. Items with the lowest priority
+// are popped first; items with equal priorities are popped in the order
+// they were pushed. Besides Push/Pop, the queue can be iterated in priority
+// order. Note: all methods are not goroutine-safe.
+type PQueue struct {
+    t   *RbMap
+    seq uint64
+}
+
+// Tree key: priority, with push sequence as tie-breaker.
+type pqKey struct {
+    priority interface{}
+    seq      uint64
+}
+
+// Create new empty priority queue, with priorities compared by less.
+func NewPQueue(less LessFunc) *PQueue {
+    return &PQueue{t: NewRbMap(func(k1, k2 interface{}) bool {
+        a, b := k1.(pqKey), k2.(pqKey)
+        if less(a.priority, b.priority) {
+            return true
+        }
+        return !less(b.priority, a.priority) && a.seq < b.seq
+    })}
+}
+
+// Returns number of items in the queue.
+func (q *PQueue) Len() int {
+    return q.t.Size()
+}
+
+// Add item with the given priority.
+func (q *PQueue) Push(priority, item interface{}) {
+    q.seq++
+    q.t.Insert(pqKey{priority, q.seq}, item)
+}
+
+// Returns item with the lowest priority, without removing it. ok is false
+// if the queue is empty.
+func (q *PQueue) Peek() (priority, item interface{}, ok bool) {
+    n := q.t.First()
+    if n == nil {
+        return nil, nil, false
+    }
+    return n.key.(pqKey).priority, n.Value, true
+}
+
+// Remove and return item with the lowest priority. ok is false if the queue
+// is empty.
+func (q *PQueue) Pop() (priority, item interface{}, ok bool) {
+    n := q.t.First()
+    if n == nil {
+        return nil, nil, false
+    }
+    priority, item = n.key.(pqKey).priority, n.Value
+    q.t.DeleteNode(n)
+    return priority, item, true
+}
+
+// Iterate over items in the order they would be popped, until f returns
+// false. The queue is not modified.
+func (q *PQueue) Range(f func(priority, item interface{}) bool) {
+    for n := q.t.First(); n != nil; n = n.Next() {
+        if !f(n.key.(pqKey).priority, n.Value) {
+            return
+        }
+    }
+}
+
+// Move all items of other into q, leaving other empty. Items of other are
+// ordered after items of q with equal priority. Both queues must use the
+// same priority ordering. Both queues are walked in order once and q is
+// relinked, so merge costs O(n+m). Merging q into itself does nothing.
+func (q *PQueue) Merge(other *PQueue) {
+    if other == q {
+        return
+    }
+    // renumber items of other after those of q, which keeps their order
+    nodes := make([]*RbMapNode, 0, other.Len())
+    for n := other.t.First(); n != nil; n = n.Next() {
+        q.seq++
+        nodes = append(nodes, &RbMapNode{key: pqKey{n.key.(pqKey).priority, q.seq}, Value: n.Value})
+    }
+    o := NewRbMap(q.t.less)
+    o.build(nodes)
+    q.t.Merge(o, false)
+    other.t.Clear()
+}
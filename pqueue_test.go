@@ -0,0 +1,41 @@
+package rbt
+
+import (
+    "testing"
+)
+
+func TestPQueue(t *testing.T) {
+    less := func(a, b interface{}) bool { return a.(int) < b.(int) }
+    q := NewPQueue(less)
+    if _, _, ok := q.Pop(); ok {
+        t.Fatalf("pop from empty queue")
+    }
+    q.Push(3, "c")
+    q.Push(1, "a1")
+    q.Push(2, "b")
+    q.Push(1, "a2")
+    if p, it, ok := q.Peek(); !ok || p != 1 || it != "a1" || q.Len() != 4 {
+        t.Fatalf("peek: %v %v %v", p, it, ok)
+    }
+    o := NewPQueue(less)
+    o.Push(1, "a3")
+    o.Push(0, "z")
+    q.Merge(o)
+    if o.Len() != 0 || q.Len() != 6 {
+        t.Fatalf("merge: lengths %d/%d", q.Len(), o.Len())
+    }
+    var got string
+    for q.Len() > 0 {
+        _, it, _ := q.Pop()
+        got += it.(string) + " "
+    }
+    if got != "z a1 a2 a3 b c " {
+        t.Fatalf("pop order: got %q", got)
+    }
+    q.Push(1, "x")
+    q.Merge(q)
+    if q.Len() != 1 {
+        t.Fatalf("merge into itself: length %d", q.Len())
+    }
+    q.t.verify()
+}
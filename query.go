@@ -0,0 +1,283 @@
+package rbt
+
+const (
+    fnvOffset64 = 14695981039346656037
+    fnvPrime64  = 1099511628211
+)
+
+// Returns order-sensitive 64-bit checksum of tree contents, combining
+// hashKey and hashValue of every entry in ascending key order together with
+// the number of entries. Equal trees have equal checksums; different trees
+// have different checksums only as far as the provided hashers allow. The
+// checksum is not cryptographic and must not be used to detect tampering.
+func (t *RbMap) Checksum(hashKey, hashValue func(interface{}) uint64) uint64 {
+    h := mix64(fnvOffset64, uint64(t.size))
+    for n := t.First(); n != nil; n = n.Next() {
+        h = mix64(h, hashKey(n.key))
+        h = mix64(h, hashValue(n.Value))
+    }
+    return h
+}
+
+// Fold 64-bit value into hash state, byte by byte in FNV-1a fashion.
+func mix64(h, v uint64) uint64 {
+    for i := 0; i < 8; i++ {
+        h ^= v & 0xff
+        h *= fnvPrime64
+        v >>= 8
+    }
+    return h
+}
+
+// Find entries sharing the same value. Entries are grouped by
+// valueKey(value), and the result maps each grouping key shared by more than
+// one entry to the []interface{} of original keys in ascending order.
+// Grouping keys are ordered by less.
+func (t *RbMap) DuplicateValues(valueKey func(interface{}) interface{}, less LessFunc) *RbMap {
+    groups := NewRbMap(less)
+    for n := t.First(); n != nil; n = n.Next() {
+        g, _ := groups.insertNode(valueKey(n.Value))
+        keys, _ := g.Value.([]interface{})
+        g.Value = append(keys, n.key)
+    }
+    dups := NewRbMap(less)
+    for g := groups.First(); g != nil; g = g.Next() {
+        if len(g.Value.([]interface{})) > 1 {
+            dups.Insert(g.key, g.Value)
+        }
+    }
+    return dups
+}
+
+// Returns depth of node with the given key, i.e. the number of edges from
+// the root to it, or -1 if key is not found.
+func (t *RbMap) DepthOf(key interface{}) int {
+    x := t.root
+    for d := 0; x != nil; d++ {
+        if t.less(x.key, key) {
+            x = x.right
+        } else if t.less(key, x.key) {
+            x = x.left
+        } else {
+            return d
+        }
+    }
+    return -1
+}
+
+// Summarize keys as a list of [start, end] intervals. Keys are walked in
+// ascending order; while gap(prevKey, key) reports that consecutive keys are
+// contiguous (no gap between them), the current interval is extended,
+// otherwise a new interval is started. Returns nil for empty tree.
+func (t *RbMap) CoveredIntervals(gap func(a, b interface{}) bool) [][2]interface{} {
+    var iv [][2]interface{}
+    for n := t.First(); n != nil; n = n.Next() {
+        if len(iv) > 0 && gap(iv[len(iv)-1][1], n.key) {
+            iv[len(iv)-1][1] = n.key
+        } else {
+            iv = append(iv, [2]interface{}{n.key, n.key})
+        }
+    }
+    return iv
+}
+
+// Returns median entry, i.e. entry at zero-based position (Size()-1)/2 in
+// ascending key order, or nil if tree is empty. For even number of entries
+// this is the lower median; the upper one is its Next(). Costs O(log n),
+// as tree nodes keep subtree sizes.
+func (t *RbMap) Median() *RbMapNode {
+    return t.Select((t.size - 1) / 2)
+}
+
+// Returns entry near quantile p (0 <= p <= 1), i.e. entry at position
+// round(p*(Size()-1)) in ascending key order; p outside of [0, 1] is
+// clamped. Returns nil for empty tree. Since tree nodes keep subtree sizes,
+// the result is in fact exact and is found in O(log n).
+func (t *RbMap) ApproxQuantile(p float64) *RbMapNode {
+    if p < 0 {
+        p = 0
+    } else if p > 1 {
+        p = 1
+    }
+    return t.Select(int(p*float64(t.size-1) + 0.5))
+}
+
+// Returns node with the greatest value as ordered by valueLess, nil if tree
+// is empty. Of several greatest values, the one with the lowest key wins.
+// Values are not indexed, so this is a linear scan.
+func (t *RbMap) MaxBy(valueLess func(a, b interface{}) bool) *RbMapNode {
+    m := t.First()
+    for n := m; n != nil; n = n.Next() {
+        if valueLess(m.Value, n.Value) {
+            m = n
+        }
+    }
+    return m
+}
+
+// Returns node with the smallest value as ordered by valueLess, nil if tree
+// is empty. Of several smallest values, the one with the lowest key wins.
+// Values are not indexed, so this is a linear scan.
+func (t *RbMap) MinBy(valueLess func(a, b interface{}) bool) *RbMapNode {
+    m := t.First()
+    for n := m; n != nil; n = n.Next() {
+        if valueLess(n.Value, m.Value) {
+            m = n
+        }
+    }
+    return m
+}
+
+// Find consecutive nodes lo and hi with lo.Value <= target <= hi.Value,
+// for values which do not decrease in ascending key order, as ordered by
+// valueLess. lo is the last node with value not greater than target, hi is
+// its successor. lo is nil if target is below all values, hi is nil if it
+// is not below the last value. Costs O(log n).
+func (t *RbMap) BracketByValue(target interface{}, valueLess func(a, b interface{}) bool) (lo, hi *RbMapNode) {
+    x := t.root
+    for x != nil {
+        if valueLess(target, x.Value) {
+            x = x.left
+        } else {
+            lo = x
+            x = x.right
+        }
+    }
+    if lo == nil {
+        return nil, t.First()
+    }
+    return lo, lo.Next()
+}
+
+// For each entry in ascending key order, emit the average of value() over
+// entries with keys in [windowBefore(key), key], until emit returns false.
+// windowBefore must not decrease as keys grow. The window slides over the
+// tree with two pointers, so the whole walk costs O(n).
+func (t *RbMap) MovingAverage(windowBefore func(key interface{}) interface{}, value func(interface{}) float64, emit func(key interface{}, avg float64) bool) {
+    lo, sum, cnt := t.First(), 0.0, 0
+    for n := lo; n != nil; n = n.Next() {
+        sum += value(n.Value)
+        cnt++
+        for start := windowBefore(n.key); lo != n && t.less(lo.key, start); lo = lo.Next() {
+            sum -= value(lo.Value)
+            cnt--
+        }
+        if !emit(n.key, sum/float64(cnt)) {
+            return
+        }
+    }
+}
+
+// Returns the first node, in ascending key order, at which the running sum
+// of weight(node.Value) reaches target, or nil if the total weight stays
+// below it. Weights are not kept in tree nodes, so this is an O(n) walk.
+func (t *RbMap) SeekByCumulative(target float64, weight func(interface{}) float64) *RbMapNode {
+    sum := 0.0
+    for n := t.First(); n != nil; n = n.Next() {
+        if sum += weight(n.Value); sum >= target {
+            return n
+        }
+    }
+    return nil
+}
+
+// Split key range into at most n shards with approximately equal number of
+// entries, for parallel scans of a tree which is not modified meanwhile.
+// Each shard is returned as inclusive [first key, last key] pair; shards
+// are in ascending order and do not overlap. Fewer shards are returned if
+// the tree has less than n entries, none if it is empty or n < 1. Uses
+// subtree sizes, costs O(n log Size()).
+func (t *RbMap) SplitRanges(n int) [][2]interface{} {
+    if n > t.size {
+        n = t.size
+    }
+    if n < 1 {
+        return nil
+    }
+    r := make([][2]interface{}, n)
+    for i := range r {
+        r[i][0] = t.Select(i * t.size / n).key
+        r[i][1] = t.Select((i+1)*t.size/n - 1).key
+    }
+    return r
+}
+
+// Partition entries into two new trees: low with k smallest entries and
+// high with the rest. The split point is found by rank in O(log n), both
+// halves are built from copies of the entries in O(n). t is unchanged. k is
+// clamped to [0, Size()].
+func (t *RbMap) SplitAtRank(k int) (low, high *RbMap) {
+    k = max(0, min(k, t.size))
+    lo, hi := make([]*RbMapNode, 0, k), make([]*RbMapNode, 0, t.size-k)
+    for n := t.First(); len(lo) < k; n = n.Next() {
+        lo = append(lo, &RbMapNode{key: n.key, Value: n.Value})
+    }
+    for n := t.Select(k); n != nil; n = n.Next() {
+        hi = append(hi, &RbMapNode{key: n.key, Value: n.Value})
+    }
+    low, high = NewRbMap(t.less), NewRbMap(t.less)
+    low.build(lo)
+    high.build(hi)
+    return low, high
+}
+
+// Returns up to k nodes with keys nearest to the given key, ordered by
+// increasing distance dist(nodeKey, key). Search expands from the position
+// of key in both directions, so dist must grow as keys get farther from
+// key in either direction. On equal distance the lower key goes first.
+func (t *RbMap) KNearest(key interface{}, k int, dist func(a, b interface{}) float64) []*RbMapNode {
+    var r []*RbMapNode
+    hi := t.LowerBound(key)
+    var lo *RbMapNode
+    if hi != nil {
+        lo = hi.Prev()
+    } else {
+        lo = t.Last()
+    }
+    for len(r) < k && (lo != nil || hi != nil) {
+        if hi == nil || (lo != nil && dist(lo.key, key) <= dist(hi.key, key)) {
+            r = append(r, lo)
+            lo = lo.Prev()
+        } else {
+            r = append(r, hi)
+            hi = hi.Next()
+        }
+    }
+    return r
+}
+
+// Returns the lowest common ancestor of key1 and key2 positions: the first
+// node on the way down from the root whose key lies between key1 and key2
+// inclusive, in either order. All keys of the range that are in the tree
+// are in this node's subtree, so it is the split point for range queries.
+// Returns nil if the tree has no keys in the range.
+func (t *RbMap) LCA(key1, key2 interface{}) *RbMapNode {
+    if t.less(key2, key1) {
+        key1, key2 = key2, key1
+    }
+    x := t.root
+    for x != nil {
+        if t.less(x.key, key1) {
+            x = x.right
+        } else if t.less(key2, x.key) {
+            x = x.left
+        } else {
+            return x
+        }
+    }
+    return nil
+}
+
+// Returns entries at fractional positions: for every fraction in [0, 1],
+// the entry at position round(fraction*(Size()-1)) in ascending key order.
+// Fractions out of [0, 1] (or any fraction, if tree is empty) yield nil.
+// Uses subtree sizes, O(log n) per fraction.
+func (t *RbMap) SampleAt(fractions []float64) []*RbMapNode {
+    r := make([]*RbMapNode, len(fractions))
+    for i, f := range fractions {
+        if f >= 0 && f <= 1 {
+            r[i] = t.Select(int(f*float64(t.size-1) + 0.5))
+        }
+    }
+    return r
+}
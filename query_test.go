@@ -0,0 +1,340 @@
+package rbt
+
+import (
+    "math/rand"
+    "testing"
+)
+
+func TestChecksum(t *testing.T) {
+    hk := func(k interface{}) uint64 { return uint64(k.(string)[0]) }
+    hv := func(v interface{}) uint64 { return uint64(v.(int)) }
+    a := newstrtree("a", "b", "c")
+    b := newstrtree("a", "b", "c")
+    if a.Checksum(hk, hv) != b.Checksum(hk, hv) {
+        t.Fatalf("equal trees have different checksums")
+    }
+    b.Insert("b", 5)
+    if a.Checksum(hk, hv) == b.Checksum(hk, hv) {
+        t.Fatalf("changed value not detected")
+    }
+    // swap values between keys: same multiset, different order
+    c := newstrtree("a", "b", "c")
+    c.Insert("a", 1)
+    c.Insert("b", 0)
+    if a.Checksum(hk, hv) == c.Checksum(hk, hv) {
+        t.Fatalf("checksum is not order-sensitive")
+    }
+    if newstrtree().Checksum(hk, hv) == newstrtree("a").Checksum(hk, hv) {
+        t.Fatalf("empty tree checksum collision")
+    }
+}
+
+func TestDuplicateValues(t *testing.T) {
+    r := newstrtree("a", "b", "c", "d", "e")
+    // values 0..4, grouped by parity
+    parity := func(v interface{}) interface{} { return v.(int) % 2 }
+    r.Insert("e", 5)
+    d := r.DuplicateValues(parity, func(k1, k2 interface{}) bool {
+        return k1.(int) < k2.(int)
+    })
+    if d.Size() != 2 {
+        t.Fatalf("duplicate groups: got %d", d.Size())
+    }
+    odd := d.Find(1).([]interface{})
+    if len(odd) != 3 || odd[0] != "b" || odd[1] != "d" || odd[2] != "e" {
+        t.Fatalf("odd group: got %v", odd)
+    }
+    r.Insert("e", 6)
+    r.Delete("d")
+    d = r.DuplicateValues(parity, func(k1, k2 interface{}) bool {
+        return k1.(int) < k2.(int)
+    })
+    if d.Size() != 1 || d.Find(1) != nil {
+        t.Fatalf("singleton group reported")
+    }
+}
+
+func TestDepthOf(t *testing.T) {
+    r := newtree(t, 10000)
+    if d := r.DepthOf(r.root.Key()); d != 0 {
+        t.Fatalf("root depth %d", d)
+    }
+    if d := r.DepthOf(-1); d != -1 {
+        t.Fatalf("missing key depth %d", d)
+    }
+    for n := r.First(); n != nil; n = n.Next() {
+        d := 0
+        for p := n.parent; p != nil; p = p.parent {
+            d++
+        }
+        if got := r.DepthOf(n.Key()); got != d {
+            t.Fatalf("key %v: depth %d, want %d", n.Key(), got, d)
+        }
+    }
+}
+
+func TestCoveredIntervals(t *testing.T) {
+    r := NewRbMap(func(k1, k2 interface{}) bool {
+        return k1.(int) < k2.(int)
+    })
+    if r.CoveredIntervals(nil) != nil {
+        t.Fatalf("intervals of empty tree")
+    }
+    for _, k := range []int{1, 2, 3, 5, 7, 8, 10} {
+        r.Insert(k, nil)
+    }
+    iv := r.CoveredIntervals(func(a, b interface{}) bool { return b.(int) == a.(int)+1 })
+    want := [][2]interface{}{{1, 3}, {5, 5}, {7, 8}, {10, 10}}
+    if len(iv) != len(want) {
+        t.Fatalf("intervals: got %v, want %v", iv, want)
+    }
+    for i := range want {
+        if iv[i] != want[i] {
+            t.Fatalf("intervals: got %v, want %v", iv, want)
+        }
+    }
+}
+
+func TestMedian(t *testing.T) {
+    if newinttree().Median() != nil {
+        t.Fatalf("median of empty tree")
+    }
+    r := newinttree()
+    for i := 1; i <= 100; i++ {
+        r.Insert(i, i)
+        if m := r.Median().Key().(int); m != (i+1)/2 {
+            t.Fatalf("median of 1..%d: got %d", i, m)
+        }
+    }
+    for i := 1; i < 100; i++ {
+        r.Delete(i)
+        if m := r.Median().Key().(int); m != i+1+(99-i)/2 {
+            t.Fatalf("median of %d..100: got %d", i+1, m)
+        }
+    }
+}
+
+func TestApproxQuantile(t *testing.T) {
+    if newinttree().ApproxQuantile(0.5) != nil {
+        t.Fatalf("quantile of empty tree")
+    }
+    r := newinttree()
+    for i := 0; i <= 100; i++ {
+        r.Insert(i, i)
+    }
+    for _, tc := range []struct {
+        p    float64
+        want int
+    }{{0, 0}, {0.25, 25}, {0.5, 50}, {0.999, 100}, {1, 100}, {-1, 0}, {2, 100}} {
+        if k := r.ApproxQuantile(tc.p).Key(); k != tc.want {
+            t.Fatalf("quantile %v: got %v, want %d", tc.p, k, tc.want)
+        }
+    }
+}
+
+func TestMaxMinBy(t *testing.T) {
+    less := func(a, b interface{}) bool { return a.(int) < b.(int) }
+    if newinttree().MaxBy(less) != nil || newinttree().MinBy(less) != nil {
+        t.Fatalf("extremes of empty tree")
+    }
+    r := newstrtree("a", "b", "c", "d")
+    r.Insert("a", 5)
+    r.Insert("b", 9)
+    r.Insert("c", 9)
+    r.Insert("d", 5)
+    if n := r.MaxBy(less); n.Key() != "b" {
+        t.Fatalf("max by value: got %v", n.Key())
+    }
+    if n := r.MinBy(less); n.Key() != "a" {
+        t.Fatalf("min by value: got %v", n.Key())
+    }
+}
+
+func TestBracketByValue(t *testing.T) {
+    r := newinttree()
+    for i := 0; i < 10; i++ {
+        r.Insert(i, i*10)
+    }
+    less := func(a, b interface{}) bool { return a.(int) < b.(int) }
+    key := func(n *RbMapNode) interface{} {
+        if n == nil {
+            return nil
+        }
+        return n.Key()
+    }
+    for _, tc := range []struct {
+        target int
+        lo, hi interface{}
+    }{
+        {-5, nil, 0},
+        {0, 0, 1},
+        {35, 3, 4},
+        {40, 4, 5},
+        {90, 9, nil},
+        {95, 9, nil},
+    } {
+        lo, hi := r.BracketByValue(tc.target, less)
+        if key(lo) != tc.lo || key(hi) != tc.hi {
+            t.Fatalf("bracket %d: got %v, %v", tc.target, key(lo), key(hi))
+        }
+    }
+    if lo, hi := newinttree().BracketByValue(1, less); lo != nil || hi != nil {
+        t.Fatalf("bracket in empty tree")
+    }
+}
+
+func TestMovingAverage(t *testing.T) {
+    r := newinttree(1, 2, 3, 10, 11, 20)
+    var got []float64
+    r.MovingAverage(func(k interface{}) interface{} { return k.(int) - 2 },
+        func(v interface{}) float64 { return float64(v.(int)) },
+        func(k interface{}, avg float64) bool {
+            got = append(got, avg)
+            return true
+        })
+    want := []float64{1, 1.5, 2, 10, 10.5, 20}
+    if len(got) != len(want) {
+        t.Fatalf("moving average: got %v", got)
+    }
+    for i := range want {
+        if got[i] != want[i] {
+            t.Fatalf("moving average: got %v, want %v", got, want)
+        }
+    }
+}
+
+func TestSeekByCumulative(t *testing.T) {
+    r := newstrtree("a", "b", "c", "d") // values 0..3
+    w := func(v interface{}) float64 { return float64(v.(int)) }
+    for _, tc := range []struct {
+        target float64
+        key    interface{}
+    }{
+        {-1, "a"}, {0, "a"}, {0.5, "b"}, {1, "b"}, {3, "c"}, {3.5, "d"}, {6, "d"}, {6.5, nil},
+    } {
+        n := r.SeekByCumulative(tc.target, w)
+        if (n == nil) != (tc.key == nil) || (n != nil && n.Key() != tc.key) {
+            t.Fatalf("seek %v: got %v", tc.target, n)
+        }
+    }
+}
+
+func TestSplitRanges(t *testing.T) {
+    r := newinttree()
+    for i := 0; i < 10; i++ {
+        r.Insert(i, i)
+    }
+    got := r.SplitRanges(3)
+    want := [][2]interface{}{{0, 2}, {3, 5}, {6, 9}}
+    if len(got) != len(want) {
+        t.Fatalf("split: got %v, want %v", got, want)
+    }
+    for i := range want {
+        if got[i] != want[i] {
+            t.Fatalf("split: got %v, want %v", got, want)
+        }
+    }
+    if len(r.SplitRanges(20)) != 10 || r.SplitRanges(0) != nil || newinttree().SplitRanges(2) != nil {
+        t.Fatalf("split with too many shards or empty tree")
+    }
+}
+
+func TestSplitAtRank(t *testing.T) {
+    r := newinttree(1, 3, 5, 7, 9)
+    for k := -1; k <= 6; k++ {
+        low, high := r.SplitAtRank(k)
+        low.verify()
+        high.verify()
+        want := max(0, min(k, 5))
+        if low.Size() != want || high.Size() != 5-want {
+            t.Fatalf("split at %d: sizes %d, %d", k, low.Size(), high.Size())
+        }
+        if want > 0 && low.Last().Key() != 2*want-1 {
+            t.Fatalf("split at %d: low ends at %v", k, low.Last().Key())
+        }
+        if want < 5 && high.First().Key() != 2*want+1 {
+            t.Fatalf("split at %d: high starts at %v", k, high.First().Key())
+        }
+    }
+    if r.Size() != 5 {
+        t.Fatalf("source tree modified")
+    }
+}
+
+func TestKNearest(t *testing.T) {
+    r := newinttree(1, 4, 6, 7, 15, 20)
+    dist := func(a, b interface{}) float64 {
+        d := a.(int) - b.(int)
+        if d < 0 {
+            d = -d
+        }
+        return float64(d)
+    }
+    for _, tc := range []struct {
+        key, k int
+        want   []int
+    }{
+        {5, 3, []int{4, 6, 7}},
+        {6, 4, []int{6, 7, 4, 1}},
+        {30, 2, []int{20, 15}},
+        {-5, 2, []int{1, 4}},
+        {10, 10, []int{7, 6, 15, 4, 1, 20}},
+        {10, 0, nil},
+    } {
+        got := r.KNearest(tc.key, tc.k, dist)
+        if len(got) != len(tc.want) {
+            t.Fatalf("%d nearest to %d: got %d nodes", tc.k, tc.key, len(got))
+        }
+        for i, n := range got {
+            if n.Key() != tc.want[i] {
+                t.Fatalf("%d nearest to %d: node %d is %v, want %d", tc.k, tc.key, i, n.Key(), tc.want[i])
+            }
+        }
+    }
+}
+
+func TestLCA(t *testing.T) {
+    if newinttree().LCA(1, 2) != nil {
+        t.Fatalf("LCA in empty tree")
+    }
+    r := newtree(t, 1000)
+    for i := 0; i < 100; i++ {
+        a, b := r.Select(rand.Intn(r.Size())), r.Select(rand.Intn(r.Size()))
+        l := r.LCA(b.Key(), a.Key())
+        // l must be an ancestor of (or equal to) both nodes
+        for _, n := range []*RbMapNode{a, b} {
+            found := n == l
+            n.Ancestors(func(p *RbMapNode) bool {
+                found = found || p == l
+                return !found
+            })
+            if !found {
+                t.Fatalf("LCA of %v and %v is not their ancestor", a.Key(), b.Key())
+            }
+        }
+        if l != a && l != b && (l.left == nil || l.right == nil) {
+            t.Fatalf("LCA is not the lowest common ancestor")
+        }
+    }
+    n := r.First()
+    if l := r.LCA(n.Key().(int)-2, n.Key().(int)-1); l != nil {
+        t.Fatalf("LCA of empty range: %v", l.Key())
+    }
+}
+
+func TestSampleAt(t *testing.T) {
+    r := newinttree()
+    for i := 0; i <= 10; i++ {
+        r.Insert(i*10, i)
+    }
+    s := r.SampleAt([]float64{0, 0.5, 1, -0.1, 1.5, 0.34})
+    want := []interface{}{0, 50, 100, nil, nil, 30}
+    for i, n := range s {
+        if (n == nil) != (want[i] == nil) || (n != nil && n.Key() != want[i]) {
+            t.Fatalf("sample %d: got %v, want %v", i, n, want[i])
+        }
+    }
+    if s := newinttree().SampleAt([]float64{0, 1}); s[0] != nil || s[1] != nil {
+        t.Fatalf("sample of empty tree")
+    }
+}
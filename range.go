@@ -0,0 +1,277 @@
+package rbt
+
+import (
+    "context"
+    "iter"
+    "sort"
+)
+
+// Iterate over entries in ascending key order, until f returns false.
+func (t *RbMap) Range(f func(key, value interface{}) bool) {
+    for n := t.First(); n != nil; n = n.Next() {
+        if !f(n.key, n.Value) {
+            return
+        }
+    }
+}
+
+// Iterate over entries whose keys start with prefix, in ascending key
+// order, until f returns false. Keys must be strings ordered by the
+// lexicographic (byte-wise) less function, e.g. k1.(string) < k2.(string).
+func (t *RbMap) RangePrefix(prefix string, f func(key, value interface{}) bool) {
+    end, bounded := prefixEnd(prefix)
+    for n := t.LowerBound(prefix); n != nil; n = n.Next() {
+        if bounded && !t.less(n.key, end) {
+            return
+        }
+        if !f(n.key, n.Value) {
+            return
+        }
+    }
+}
+
+// Returns the smallest string greater than all strings with given prefix,
+// obtained by incrementing the last byte which is not 0xff. If there is no
+// such byte, the prefix range is unbounded and false is returned.
+func prefixEnd(prefix string) (string, bool) {
+    b := []byte(prefix)
+    for i := len(b) - 1; i >= 0; i-- {
+        if b[i] < 0xff {
+            b[i]++
+            return string(b[:i+1]), true
+        }
+    }
+    return "", false
+}
+
+// Visit entries in ascending key order, stopping at the first non-nil error
+// returned by f. Returns that error, or nil if all entries were visited.
+func (t *RbMap) Walk(f func(key, value interface{}) error) error {
+    for n := t.First(); n != nil; n = n.Next() {
+        if err := f(n.key, n.Value); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// Iterate over every stride-th entry in ascending key order, i.e. entries at
+// positions 0, stride, 2*stride and so on, until f returns false. Panics if
+// stride is less than 1.
+func (t *RbMap) RangeStride(stride int, f func(key, value interface{}) bool) {
+    if stride < 1 {
+        panic("rbt: stride must be positive")
+    }
+    for n := t.First(); n != nil; {
+        if !f(n.key, n.Value) {
+            return
+        }
+        for i := 0; n != nil && i < stride; i++ {
+            n = n.Next()
+        }
+    }
+}
+
+// Iterate over pairs of adjacent entries (entry i and i+1) in ascending key
+// order, until f returns false. Trees with less than two entries produce no
+// calls.
+func (t *RbMap) RangePairs(f func(aKey, aValue, bKey, bValue interface{}) bool) {
+    a := t.First()
+    if a == nil {
+        return
+    }
+    for b := a.Next(); b != nil; a, b = b, b.Next() {
+        if !f(a.key, a.Value, b.key, b.Value) {
+            return
+        }
+    }
+}
+
+// Iterate over all entries treating them as a ring, until f returns false.
+// Iteration begins at the first entry with key not less than start, goes up
+// to the last entry, then wraps around to the first entry and continues up
+// to (not including) the starting one, so every entry is visited once. If
+// all keys are less than start, iteration begins at the first entry.
+func (t *RbMap) RangeCircular(start interface{}, f func(key, value interface{}) bool) {
+    s := t.LowerBound(start)
+    if s == nil {
+        s = t.First()
+    }
+    for n := s; n != nil; n = n.Next() {
+        if !f(n.key, n.Value) {
+            return
+        }
+    }
+    for n := t.First(); n != s; n = n.Next() {
+        if !f(n.key, n.Value) {
+            return
+        }
+    }
+}
+
+// Iterate over nodes in ascending key order together with their previous
+// and next nodes (nil at the ends), until f returns false.
+func (t *RbMap) RangeWithNeighbors(f func(prev, cur, next *RbMapNode) bool) {
+    var prev *RbMapNode
+    for cur := t.First(); cur != nil; {
+        next := cur.Next()
+        if !f(prev, cur, next) {
+            return
+        }
+        prev, cur = cur, next
+    }
+}
+
+// Fold entries in ascending key order, starting with initial accumulator.
+// For every entry, f returns the new accumulator and a value to emit, which
+// is passed to out together with the entry key. Stops when out returns
+// false. E.g. running totals are computed with f returning (acc+v, acc+v).
+func (t *RbMap) Scan(initial interface{}, f func(acc, key, value interface{}) (newAcc interface{}, emit interface{}), out func(key, emit interface{}) bool) {
+    acc := initial
+    for n := t.First(); n != nil; n = n.Next() {
+        var emit interface{}
+        acc, emit = f(acc, n.key, n.Value)
+        if !out(n.key, emit) {
+            return
+        }
+    }
+}
+
+// Iterate over entries aligned to a sequence of target keys: anchor,
+// step(anchor), step(step(anchor)) and so on. For every target the first
+// entry with key not less than it is visited, unless it was already visited
+// for an earlier target, in which case targets are skipped up to that
+// entry's key. step must return strictly increasing keys. Every jump is an
+// O(log n) search, so sparse sampling does not walk all entries. Stops when
+// f returns false or there are no more entries.
+func (t *RbMap) RangeAligned(anchor interface{}, step func(key interface{}) interface{}, f func(key, value interface{}) bool) {
+    target := anchor
+    for {
+        n := t.LowerBound(target)
+        if n == nil || !f(n.key, n.Value) {
+            return
+        }
+        for !t.less(n.key, target) {
+            target = step(target)
+        }
+    }
+}
+
+// Iterate over nodes from begin up to, but not including, end, in ascending
+// key order, until f returns false. Nil end iterates to the last node. end
+// must not precede begin, e.g. both may come from the same key range
+// search, which lets the range be scanned without further comparisons.
+func RangeNodes(begin, end *RbMapNode, f func(n *RbMapNode) bool) {
+    for n := begin; n != nil && n != end; n = n.Next() {
+        if !f(n) {
+            return
+        }
+    }
+}
+
+// Iterate over entries in ascending value order as defined by valueLess,
+// until f returns false. Entries with equal values are visited in key
+// order. Values are not indexed, so this copies all entries and sorts them,
+// which costs O(n log n) time and O(n) memory on every call.
+func (t *RbMap) RangeByValue(valueLess func(a, b interface{}) bool, f func(key, value interface{}) bool) {
+    e := t.Entries()
+    sort.SliceStable(e, func(i, j int) bool {
+        return valueLess(e[i].Value, e[j].Value)
+    })
+    for _, x := range e {
+        if !f(x.Key, x.Value) {
+            return
+        }
+    }
+}
+
+// How many entries RangeCtx visits between checks of context.
+const ctxCheckInterval = 64
+
+// Iterate over entries in ascending key order, until f returns false or ctx
+// is done. Context is checked before the first entry and then every few
+// entries. Returns ctx.Err() if iteration was cut short by the context,
+// nil otherwise.
+func (t *RbMap) RangeCtx(ctx context.Context, f func(key, value interface{}) bool) error {
+    i := 0
+    for n := t.First(); n != nil; n = n.Next() {
+        if i%ctxCheckInterval == 0 {
+            if err := ctx.Err(); err != nil {
+                return err
+            }
+        }
+        i++
+        if !f(n.key, n.Value) {
+            return nil
+        }
+    }
+    return nil
+}
+
+// Iterate in ascending key order over entries whose values satisfy pred,
+// until f returns false.
+func (t *RbMap) RangeWhere(pred func(value interface{}) bool, f func(key, value interface{}) bool) {
+    for n := t.First(); n != nil; n = n.Next() {
+        if pred(n.Value) && !f(n.key, n.Value) {
+            return
+        }
+    }
+}
+
+// Iterate over entries in ascending key order, until f returns false,
+// skipping subtrees rejected by shouldEnter. Before descending into a
+// subtree, shouldEnter is called with its root node and the nearest
+// ancestors bounding it: all keys of the subtree are greater than lo's key
+// and less than hi's key, where nil lo or hi means no bound on that side.
+// If shouldEnter returns false, the whole subtree, including its root, is
+// skipped. For example, to visit keys in [a, b] only, reject subtrees with
+// hi.Key() <= a or lo.Key() >= b; nodes outside of [a, b] are still visited
+// on the way to the bounds, so f has to check keys.
+func (t *RbMap) RangePrune(shouldEnter func(node, lo, hi *RbMapNode) bool, f func(key, value interface{}) bool) {
+    var walk func(n, lo, hi *RbMapNode) bool
+    walk = func(n, lo, hi *RbMapNode) bool {
+        if n == nil || !shouldEnter(n, lo, hi) {
+            return true
+        }
+        return walk(n.left, lo, n) && f(n.key, n.Value) && walk(n.right, n, hi)
+    }
+    walk(t.root, nil, nil)
+}
+
+// Iterate over entries in median-first order until f returns false: the
+// median entry goes first, followed recursively by entries of the lower
+// half, then of the upper half. Inserting entries in this order into a
+// plain binary search tree makes it balanced. Entries are found by
+// position using subtree sizes, which costs O(n log n) in total.
+func (t *RbMap) RangeBalanced(f func(key, value interface{}) bool) {
+    var walk func(lo, hi int) bool
+    walk = func(lo, hi int) bool {
+        if lo >= hi {
+            return true
+        }
+        m := lo + (hi-lo)/2
+        n := t.Select(m)
+        return f(n.key, n.Value) && walk(lo, m) && walk(m+1, hi)
+    }
+    walk(0, t.size)
+}
+
+// Iterate over entries in ascending key order together with their 1-based
+// position, until f returns false.
+func (t *RbMap) RangeRanked(f func(rank int, key, value interface{}) bool) {
+    rank := 1
+    for n := t.First(); n != nil; n = n.Next() {
+        if !f(rank, n.key, n.Value) {
+            return
+        }
+        rank++
+    }
+}
+
+// Returns iterator over entries in ascending key order, for use with
+// range-over-func loops: for k, v := range t.All() { ... }
+func (t *RbMap) All() iter.Seq2[interface{}, interface{}] {
+    return func(yield func(key, value interface{}) bool) {
+        t.Range(yield)
+    }
+}
@@ -0,0 +1,329 @@
+package rbt
+
+import (
+    "context"
+    "errors"
+    "strconv"
+    "testing"
+)
+
+func newstrtree(keys ...string) *RbMap {
+    r := NewRbMap(func(k1, k2 interface{}) bool {
+        return k1.(string) < k2.(string)
+    })
+    for i, k := range keys {
+        r.Insert(k, i)
+    }
+    return r
+}
+
+func TestRangePrefix(t *testing.T) {
+    r := newstrtree("a", "ab", "abc", "abd", "ac", "b", "ab\xff", "ab\xff\xff")
+    var got []string
+    r.RangePrefix("ab", func(k, v interface{}) bool {
+        got = append(got, k.(string))
+        return true
+    })
+    want := []string{"ab", "abc", "abd", "ab\xff", "ab\xff\xff"}
+    if len(got) != len(want) {
+        t.Fatalf("prefix range: got %q, want %q", got, want)
+    }
+    for i := range want {
+        if got[i] != want[i] {
+            t.Fatalf("prefix range: got %q, want %q", got, want)
+        }
+    }
+    cnt := 0
+    r.RangePrefix("", func(k, v interface{}) bool { cnt++; return cnt < 3 })
+    if cnt != 3 {
+        t.Fatalf("early stop: visited %d entries", cnt)
+    }
+    r.RangePrefix("z", func(k, v interface{}) bool {
+        t.Fatalf("unexpected key %q", k)
+        return false
+    })
+}
+
+func TestWalk(t *testing.T) {
+    r := newstrtree("a", "b", "c", "d")
+    stop := errors.New("stop")
+    var seen []string
+    err := r.Walk(func(k, v interface{}) error {
+        seen = append(seen, k.(string))
+        if k.(string) == "b" {
+            return stop
+        }
+        return nil
+    })
+    if err != stop || len(seen) != 2 {
+        t.Fatalf("walk: err %v, seen %q", err, seen)
+    }
+    if err := r.Walk(func(k, v interface{}) error { return nil }); err != nil {
+        t.Fatalf("walk: unexpected error %v", err)
+    }
+}
+
+func TestRangeStride(t *testing.T) {
+    r := newstrtree("a", "b", "c", "d", "e", "f", "g")
+    var got string
+    r.RangeStride(3, func(k, v interface{}) bool {
+        got += k.(string)
+        return true
+    })
+    if got != "adg" {
+        t.Fatalf("stride 3: got %q", got)
+    }
+    got = ""
+    r.RangeStride(1, func(k, v interface{}) bool {
+        got += k.(string)
+        return len(got) < 2
+    })
+    if got != "ab" {
+        t.Fatalf("stride 1 with early stop: got %q", got)
+    }
+    defer func() {
+        if recover() == nil {
+            t.Fatalf("zero stride did not panic")
+        }
+    }()
+    r.RangeStride(0, func(k, v interface{}) bool { return true })
+}
+
+func TestRangePairs(t *testing.T) {
+    var got string
+    pairs := func(ak, av, bk, bv interface{}) bool {
+        got += ak.(string) + bk.(string) + " "
+        return true
+    }
+    newstrtree("a").RangePairs(pairs)
+    newstrtree().RangePairs(pairs)
+    if got != "" {
+        t.Fatalf("pairs of short tree: got %q", got)
+    }
+    newstrtree("c", "a", "b", "d").RangePairs(pairs)
+    if got != "ab bc cd " {
+        t.Fatalf("pairs: got %q", got)
+    }
+}
+
+func TestRangeCircular(t *testing.T) {
+    r := newstrtree("a", "c", "e", "g")
+    for _, tc := range []struct{ start, want string }{
+        {"c", "cega"}, {"d", "egac"}, {"a", "aceg"}, {"h", "aceg"},
+    } {
+        got := ""
+        r.RangeCircular(tc.start, func(k, v interface{}) bool {
+            got += k.(string)
+            return true
+        })
+        if got != tc.want {
+            t.Fatalf("start %q: got %q, want %q", tc.start, got, tc.want)
+        }
+    }
+    newstrtree().RangeCircular("a", func(k, v interface{}) bool {
+        t.Fatalf("empty tree visited %v", k)
+        return false
+    })
+}
+
+func TestRangeWithNeighbors(t *testing.T) {
+    key := func(n *RbMapNode) string {
+        if n == nil {
+            return "-"
+        }
+        return n.Key().(string)
+    }
+    var got string
+    newstrtree("b", "a", "c").RangeWithNeighbors(func(p, c, n *RbMapNode) bool {
+        got += key(p) + key(c) + key(n) + " "
+        return true
+    })
+    if got != "-ab abc bc- " {
+        t.Fatalf("neighbors: got %q", got)
+    }
+}
+
+func TestScan(t *testing.T) {
+    r := newstrtree("a", "b", "c", "d") // values 0..3
+    var sums []int
+    r.Scan(10, func(acc, k, v interface{}) (interface{}, interface{}) {
+        s := acc.(int) + v.(int)
+        return s, s
+    }, func(k, e interface{}) bool {
+        sums = append(sums, e.(int))
+        return k.(string) < "c"
+    })
+    if len(sums) != 3 || sums[0] != 10 || sums[1] != 11 || sums[2] != 13 {
+        t.Fatalf("scan: got %v", sums)
+    }
+}
+
+func TestRangeAligned(t *testing.T) {
+    r := NewRbMap(func(k1, k2 interface{}) bool {
+        return k1.(int) < k2.(int)
+    })
+    for _, k := range []int{3, 4, 5, 12, 13, 29, 31} {
+        r.Insert(k, k)
+    }
+    var got []int
+    r.RangeAligned(0, func(k interface{}) interface{} { return k.(int) + 5 },
+        func(k, v interface{}) bool {
+            got = append(got, k.(int))
+            return true
+        })
+    // targets 0,5,10,15,20,25,30 -> 3,5,12,29,31
+    want := []int{3, 5, 12, 29, 31}
+    if len(got) != len(want) {
+        t.Fatalf("aligned: got %v, want %v", got, want)
+    }
+    for i := range want {
+        if got[i] != want[i] {
+            t.Fatalf("aligned: got %v, want %v", got, want)
+        }
+    }
+}
+
+func TestRangeNodes(t *testing.T) {
+    r := newstrtree("a", "b", "c", "d")
+    var got string
+    collect := func(n *RbMapNode) bool {
+        got += n.Key().(string)
+        return true
+    }
+    RangeNodes(r.FindNode("b"), r.FindNode("d"), collect)
+    RangeNodes(r.FindNode("c"), nil, collect)
+    RangeNodes(r.FindNode("c"), r.FindNode("c"), collect)
+    if got != "bccd" {
+        t.Fatalf("range nodes: got %q", got)
+    }
+}
+
+func TestRangeByValue(t *testing.T) {
+    r := newstrtree("a", "b", "c", "d")
+    r.Insert("a", 3)
+    r.Insert("b", 1)
+    r.Insert("c", 3)
+    r.Insert("d", 0)
+    var got string
+    r.RangeByValue(func(a, b interface{}) bool { return a.(int) < b.(int) },
+        func(k, v interface{}) bool {
+            got += k.(string)
+            return len(got) < 3
+        })
+    if got != "dba" {
+        t.Fatalf("by value: got %q", got)
+    }
+}
+
+func TestRangeCtx(t *testing.T) {
+    r := newtree(t, 1000)
+    ctx, cancel := context.WithCancel(context.Background())
+    cnt := 0
+    err := r.RangeCtx(ctx, func(k, v interface{}) bool {
+        if cnt++; cnt == 100 {
+            cancel()
+        }
+        return true
+    })
+    if err != context.Canceled || cnt < 100 || cnt > 100+ctxCheckInterval {
+        t.Fatalf("canceled range: %v after %d entries", err, cnt)
+    }
+    if err := r.RangeCtx(ctx, func(k, v interface{}) bool {
+        t.Fatalf("canceled context visited %v", k)
+        return false
+    }); err != context.Canceled {
+        t.Fatalf("range with canceled context: %v", err)
+    }
+    cnt = 0
+    if err := r.RangeCtx(context.Background(), func(k, v interface{}) bool {
+        cnt++
+        return true
+    }); err != nil || cnt != r.Size() {
+        t.Fatalf("full range: %v, %d entries", err, cnt)
+    }
+}
+
+func TestRangeWhere(t *testing.T) {
+    r := newstrtree("a", "b", "c", "d", "e", "f")
+    var got string
+    r.RangeWhere(func(v interface{}) bool { return v.(int)%2 == 1 },
+        func(k, v interface{}) bool {
+            got += k.(string)
+            return k != "d"
+        })
+    if got != "bd" {
+        t.Fatalf("range where: got %q", got)
+    }
+}
+
+func TestRangePrune(t *testing.T) {
+    r := newinttree()
+    for i := 0; i < 100; i++ {
+        r.Insert(i, i)
+    }
+    calls := 0
+    // enter only subtrees which may hold keys in [40, 59]
+    enter := func(n, lo, hi *RbMapNode) bool {
+        calls++
+        return (hi == nil || hi.Key().(int) > 40) && (lo == nil || lo.Key().(int) < 59)
+    }
+    var got []int
+    r.RangePrune(enter, func(k, v interface{}) bool {
+        if k.(int) >= 40 && k.(int) < 60 {
+            got = append(got, k.(int))
+        }
+        return true
+    })
+    if len(got) != 20 || got[0] != 40 || got[19] != 59 {
+        t.Fatalf("range prune: got %v", got)
+    }
+    if calls >= 100 {
+        t.Fatalf("range prune: nothing pruned")
+    }
+    cnt := 0
+    r.RangePrune(func(n, lo, hi *RbMapNode) bool { return true }, func(k, v interface{}) bool {
+        cnt++
+        return cnt < 10
+    })
+    if cnt != 10 {
+        t.Fatalf("range prune stop: %d entries", cnt)
+    }
+}
+
+func TestRangeBalanced(t *testing.T) {
+    r := newinttree(0, 1, 2, 3, 4, 5, 6)
+    var got []int
+    r.RangeBalanced(func(k, v interface{}) bool {
+        got = append(got, k.(int))
+        return true
+    })
+    want := []int{3, 1, 0, 2, 5, 4, 6}
+    if len(got) != len(want) {
+        t.Fatalf("range balanced: got %v", got)
+    }
+    for i := range want {
+        if got[i] != want[i] {
+            t.Fatalf("range balanced: got %v, want %v", got, want)
+        }
+    }
+    cnt := 0
+    r.RangeBalanced(func(k, v interface{}) bool {
+        cnt++
+        return cnt < 3
+    })
+    if cnt != 3 {
+        t.Fatalf("range balanced stop: %d entries", cnt)
+    }
+}
+
+func TestRangeRanked(t *testing.T) {
+    r := newstrtree("c", "a", "b", "d")
+    var got string
+    r.RangeRanked(func(rank int, k, v interface{}) bool {
+        got += strconv.Itoa(rank) + k.(string)
+        return rank < 3
+    })
+    if got != "1a2b3c" {
+        t.Fatalf("range ranked: got %q", got)
+    }
+}
@@ -4,28 +4,53 @@
 // Note: all methods are not goroutine-safe.
 package rbt
 
+import (
+    "errors"
+)
+
 // import ( "strings" ; "fmt" )
 
+// ErrNilKey is returned by InsertChecked for nil keys.
+var ErrNilKey = errors.New("rbt: nil key")
+
 type RbMap struct {
     less       LessFunc
     root       *RbMapNode
     size       int
+    order      *RbMap     // insertion sequence -> key, nil if not tracked
+    seq        uint64     // last assigned insertion sequence
+    validate   func(key, value interface{}) error
+    guard      *heightGuard // nil if height checks are off
 }
 
 // Red-black tree node, contains key and value. It is safe to overwrite Value
-// in-place.
+// in-place, though this bypasses value validator; see RbMap.SetValue.
 type RbMapNode struct {
     left, right, parent *RbMapNode
     // key
     key          interface{}
     Value        interface{}
     isred        bool         // true == red, false == black
+    seq          uint64       // insertion sequence, if tracked
+    size         int          // number of nodes in subtree rooted here
 }
 
 // LessFunc is a key comparsion function. 
 // Must return true if k1 < k2, false otherwise.
 type LessFunc func(k1, k2 interface{}) bool
 
+// OrderedMap is a common interface of ordered associative containers,
+// implemented by RbMap.
+type OrderedMap interface {
+    Insert(k, v interface{}) bool
+    Get(k interface{}) (interface{}, bool)
+    Delete(k interface{}) bool
+    Len() int
+    Range(func(k, v interface{}) bool)
+}
+
+var _ OrderedMap = (*RbMap)(nil)
+
 // Create new RbMap with provided key comparsion function. 
 func NewRbMap(lessFunc LessFunc) *RbMap {
     return &RbMap{ less: lessFunc }
@@ -40,6 +65,43 @@ func (t *RbMap) Find(key interface{}) interface{} {
     return nil
 }
 
+// Find value by key, loading it on a miss. If key is not found, load(key)
+// is called, and if it returns ok, the loaded value is inserted and
+// returned. If load fails, nothing is inserted and ok is false.
+func (t *RbMap) GetOrLoad(key interface{}, load func(key interface{}) (interface{}, bool)) (interface{}, bool) {
+    if n := t.FindNode(key); n != nil {
+        return n.Value, true
+    }
+    v, ok := load(key)
+    if ok {
+        t.Insert(key, v)
+    }
+    return v, ok
+}
+
+// Find value by key. Unlike Find, reports whether key was found, so that nil
+// values can be told from missing keys.
+func (t *RbMap) Get(key interface{}) (interface{}, bool) {
+    if n := t.FindNode(key); n != nil {
+        return n.Value, true
+    }
+    return nil, false
+}
+
+// Find all nodes with keys equal to the given one under the comparison
+// function, in ascending key order. Since Insert never stores two equal
+// keys, this yields several nodes only if the comparison function is
+// coarser for the probe than for stored keys, e.g. when the probe is a
+// partial key which compares equal to all keys sharing that part.
+func (t *RbMap) FindGroup(key interface{}) []*RbMapNode {
+    var g []*RbMapNode
+    end := t.UpperBound(key)
+    for n := t.LowerBound(key); n != end; n = n.Next() {
+        g = append(g, n)
+    }
+    return g
+}
+
 // Find a node by key, returns nil if not found.
 func (t *RbMap) FindNode(key interface{}) *RbMapNode {
     x := t.root
@@ -57,6 +119,119 @@ func (t *RbMap) FindNode(key interface{}) *RbMapNode {
     return nil
 }
 
+// Find the first node with key not less than the given key, returns nil if
+// there is no such node.
+func (t *RbMap) LowerBound(key interface{}) *RbMapNode {
+    var y *RbMapNode
+    x := t.root
+    for x != nil {
+        if t.less(x.key, key) {
+            x = x.right
+        } else {
+            y = x
+            x = x.left
+        }
+    }
+    return y
+}
+
+// Find the first node with key greater than the given key, returns nil if
+// there is no such node.
+func (t *RbMap) UpperBound(key interface{}) *RbMapNode {
+    var y *RbMapNode
+    x := t.root
+    for x != nil {
+        if t.less(key, x.key) {
+            y = x
+            x = x.left
+        } else {
+            x = x.right
+        }
+    }
+    return y
+}
+
+// Find the last node with key not greater than the given key, returns nil
+// if there is no such node.
+func (t *RbMap) Floor(key interface{}) *RbMapNode {
+    var y *RbMapNode
+    x := t.root
+    for x != nil {
+        if t.less(key, x.key) {
+            x = x.left
+        } else {
+            y = x
+            x = x.right
+        }
+    }
+    return y
+}
+
+// Find the first node with key not less than the given key, returns nil if
+// there is no such node. Same as LowerBound, for symmetry with Floor.
+func (t *RbMap) Ceil(key interface{}) *RbMapNode {
+    return t.LowerBound(key)
+}
+
+// Find a node whose key is close to the given key, as decided by within.
+// Exact match is returned if present. Otherwise, the nearest neighbours
+// (smallest greater key and largest smaller key) are checked with
+// within(neighbourKey, key), the greater neighbour first, and the first one
+// satisfying it is returned. Since within does not tell distances, the
+// greater neighbour is returned even if the smaller one is nearer, when both
+// are within tolerance. Returns nil if neither neighbour is within
+// tolerance.
+func (t *RbMap) FindApprox(key interface{}, within func(a, b interface{}) bool) *RbMapNode {
+    c := t.LowerBound(key)
+    if c != nil && !t.less(key, c.key) {
+        return c
+    }
+    if c != nil && within(c.key, key) {
+        return c
+    }
+    if f := t.Floor(key); f != nil && within(f.key, key) {
+        return f
+    }
+    return nil
+}
+
+// Find node at zero-based position k in ascending key order, using subtree
+// sizes. Returns nil if k is out of range.
+func (t *RbMap) Select(k int) *RbMapNode {
+    if k < 0 || k >= t.size {
+        return nil
+    }
+    x := t.root
+    for {
+        l := nodeSize(x.left)
+        if k < l {
+            x = x.left
+        } else if k > l {
+            k -= l + 1
+            x = x.right
+        } else {
+            return x
+        }
+    }
+}
+
+// Returns number of keys less than the given key, i.e. position the key
+// has or would have in ascending key order. Costs O(log n), using subtree
+// sizes.
+func (t *RbMap) Rank(key interface{}) int {
+    r := 0
+    x := t.root
+    for x != nil {
+        if t.less(x.key, key) {
+            r += nodeSize(x.left) + 1
+            x = x.right
+        } else {
+            x = x.left
+        }
+    }
+    return r
+}
+
 // Get last node in the tree (with highest key value).
 func (t *RbMap) Last() *RbMapNode {
     if nil == t.root {
@@ -73,6 +248,28 @@ func (t *RbMap) First() *RbMapNode {
     return t.root.min()
 }
 
+// Returns the lowest and the highest keys; ok is false if tree is empty.
+func (t *RbMap) Span() (min, max interface{}, ok bool) {
+    if t.root == nil {
+        return nil, nil, false
+    }
+    return t.root.min().key, t.root.max().key, true
+}
+
+// Returns true if key is equal to the lowest key in the tree, false if it
+// is not or tree is empty. Costs O(log n).
+func (t *RbMap) IsMin(key interface{}) bool {
+    n := t.First()
+    return n != nil && !t.less(n.key, key) && !t.less(key, n.key)
+}
+
+// Returns true if key is equal to the highest key in the tree, false if it
+// is not or tree is empty. Costs O(log n).
+func (t *RbMap) IsMax(key interface{}) bool {
+    n := t.Last()
+    return n != nil && !t.less(n.key, key) && !t.less(key, n.key)
+}
+
 // Get next node, in ascending key value order.
 func (x *RbMapNode) Next() *RbMapNode {
     if x.right != nil {
@@ -91,6 +288,21 @@ func (x *RbMapNode) Key() interface{} {
     return x.key
 }
 
+// Returns parent node in the tree structure, nil for the root.
+func (x *RbMapNode) Parent() *RbMapNode {
+    return x.parent
+}
+
+// Visit ancestors of the node, from its parent up to the root, until f
+// returns false.
+func (x *RbMapNode) Ancestors(f func(*RbMapNode) bool) {
+    for p := x.parent; p != nil; p = p.parent {
+        if !f(p) {
+            return
+        }
+    }
+}
+
 // Get previous node, in descending key value order.
 func (x *RbMapNode) Prev() *RbMapNode {
     if x.left != nil {
@@ -110,15 +322,127 @@ func (t *RbMap) Size() int {
     return t.size
 }
 
+// Returns number of entries in the tree, same as Size.
+func (t *RbMap) Len() int {
+    return t.size
+}
+
 // Remove all entries in the tree.
 func (t *RbMap) Clear() {
     t.root = nil
     t.size = 0
+    if t.order != nil {
+        t.order.Clear()
+    }
+}
+
+// Exchange contents of two trees in O(1). Comparison function and other
+// settings (insertion order tracking, value validator) are exchanged too,
+// so each tree keeps being consistent with its entries.
+func (t *RbMap) Swap(other *RbMap) {
+    *t, *other = *other, *t
+}
+
+// Returns independent copy of the tree: nodes are copied with their keys,
+// values, colors and links in a single traversal, in O(n). Keys and values
+// themselves are shared. Comparison function and other settings are copied
+// too, insertion order index is cloned along with the tree.
+func (t *RbMap) Clone() *RbMap {
+    c := *t
+    c.root = clone(t.root, nil)
+    if t.order != nil {
+        c.order = t.order.Clone()
+    }
+    if t.guard != nil {
+        g := *t.guard
+        c.guard = &g
+    }
+    return &c
+}
+
+func clone(n, parent *RbMapNode) *RbMapNode {
+    if n == nil {
+        return nil
+    }
+    c := *n
+    c.parent = parent
+    c.left = clone(n.left, &c)
+    c.right = clone(n.right, &c)
+    return &c
 }
 
 // Insert key and value into the tree. If new entry is created, returns true.
 // If key already exists, value gets replaced and Insert returns false.
+// Insert passes nil keys to the comparison function as is, so it is up to
+// LessFunc to handle them; use InsertChecked to reject nil keys.
 func (t *RbMap) Insert(key interface{}, value interface{}) bool {
+    n, created := t.insertNode(key)
+    n.Value = value
+    return created
+}
+
+// Insert key and value into the tree. If key already exists, its value is
+// set to combine(old, value) and InsertWith returns false. Otherwise, new
+// entry is created with the given value and InsertWith returns true.
+func (t *RbMap) InsertWith(key, value interface{}, combine func(old, new interface{}) interface{}) bool {
+    n, created := t.insertNode(key)
+    if created {
+        n.Value = value
+    } else {
+        n.Value = combine(n.Value, value)
+    }
+    return created
+}
+
+// Add delta to int value of the entry, creating entry with value delta if
+// key is not found. Returns the new value. Value of existing entry must be
+// an int.
+func (t *RbMap) Increment(key interface{}, delta int) int {
+    n, created := t.insertNode(key)
+    if !created {
+        delta += n.Value.(int)
+    }
+    n.Value = delta
+    return delta
+}
+
+// Insert key and value into the tree, leaving existing entry untouched if
+// eq(oldValue, value) reports the values are equal. Returns true if entry
+// was created or its value was replaced.
+func (t *RbMap) InsertIfChanged(key, value interface{}, eq func(a, b interface{}) bool) (changed bool) {
+    n, created := t.insertNode(key)
+    if !created && eq(n.Value, value) {
+        return false
+    }
+    n.Value = value
+    return true
+}
+
+// Insert key and value into the tree, like Insert, but return ErrNilKey
+// instead of passing nil key to the comparison function, which would likely
+// panic on type assertion. Returns nil if entry was inserted or updated.
+func (t *RbMap) InsertChecked(key, value interface{}) error {
+    if key == nil {
+        return ErrNilKey
+    }
+    t.Insert(key, value)
+    return nil
+}
+
+// Insert key and value into the tree if valid(key, value) accepts them.
+// Returns the validation error without inserting anything, or nil if entry
+// was inserted or updated.
+func (t *RbMap) InsertValidated(key, value interface{}, valid func(key, value interface{}) error) error {
+    if err := valid(key, value); err != nil {
+        return err
+    }
+    t.Insert(key, value)
+    return nil
+}
+
+// Find node by key, or create a new one with nil Value. Returns the node and
+// true if it was created.
+func (t *RbMap) insertNode(key interface{}) (*RbMapNode, bool) {
     x := t.root
     var y *RbMapNode
 
@@ -129,11 +453,13 @@ func (t *RbMap) Insert(key interface{}, value interface{}) bool {
         } else if t.less(key, x.key) {
             x = x.left
         } else {
-            x.Value = value
-            return false // overwrite value
+            return x, false
         }
     }
-    z := &RbMapNode{parent: y, isred: true, key: key, Value: value}
+    z := &RbMapNode{parent: y, isred: true, key: key, size: 1}
+    if t.order != nil {
+        t.track(z)
+    }
     if y == nil {
         t.root = z
     } else {
@@ -143,9 +469,43 @@ func (t *RbMap) Insert(key interface{}, value interface{}) bool {
             y.right = z
         }
     }
+    for ; y != nil; y = y.parent {
+        y.size++
+    }
     t.rb_insert_fixup(z)
     t.size++
-    return true
+    if t.guard != nil {
+        t.guard.check(t)
+    }
+    return z, true
+}
+
+// Returns true if inserting key would rotate the tree, i.e. if rebalancing
+// would reach a red parent with a black uncle, possibly after recoloring
+// red uncles up the path. Returns false if key already exists. The tree is
+// not modified.
+func (t *RbMap) InsertWouldRotate(key interface{}) bool {
+    x := t.root
+    var p *RbMapNode
+    for x != nil {
+        p = x
+        if t.less(x.key, key) {
+            x = x.right
+        } else if t.less(key, x.key) {
+            x = x.left
+        } else {
+            return false
+        }
+    }
+    // p is parent of the new red node; red p is never the root
+    for isRed(p) {
+        if !isRed(p.sibling()) {
+            return true
+        }
+        // recoloring makes grandparent red, continue from there
+        p = p.parent.parent
+    }
+    return false
 }
 
 // Delete tree node by key. Returns true if key was found and deleted.
@@ -157,14 +517,40 @@ func (t *RbMap) Delete(key interface{}) bool {
     return false
 }
 
+// Move value from key from to key to. If from is not found, nothing is
+// changed and ok is false. Otherwise the entry under from is deleted and
+// its value is stored under to, replacing existing value if any, which is
+// reported by overwrote. Renaming key to an equal one is a no-op.
+func (t *RbMap) Rename(from, to interface{}) (ok bool, overwrote bool) {
+    n := t.FindNode(from)
+    if n == nil {
+        return false, false
+    }
+    if !t.less(from, to) && !t.less(to, from) {
+        return true, false
+    }
+    v := n.Value
+    t.DeleteNode(n)
+    return true, !t.Insert(to, v)
+}
+
 // Delete tree node.
 func (t *RbMap) DeleteNode(n *RbMapNode) {
+    if t.order != nil {
+        t.order.Delete(n.seq)
+    }
     var x *RbMapNode
     if nil != n.left && nil != n.right {
         x = n.left.max()
-        n.key, n.Value = x.key, x.Value
+        n.key, n.Value, n.seq = x.key, x.Value, x.seq
         n = x
     }
+    // n is about to be unlinked; drop it from subtree sizes now, so that
+    // rotations in delete fixup see consistent sizes.
+    n.size--
+    for p := n.parent; p != nil; p = p.parent {
+        p.size--
+    }
     if nil == n.right {
         x = n.left
     } else {
@@ -181,6 +567,34 @@ func (t *RbMap) DeleteNode(n *RbMapNode) {
         t.root.isred = false
     }
     t.size--
+    if t.guard != nil {
+        t.guard.check(t)
+    }
+}
+
+// Delete tree node if it is linked into this tree. Returns false, leaving
+// the tree intact, if n is nil, belongs to another tree or was unlinked from
+// this one. Deleted nodes are not detected in general: deleting a node with
+// two children keeps that node linked, holding the entry of its predecessor,
+// so it passes the check. Costs O(log n), because ownership is checked by
+// walking up to the root.
+func (t *RbMap) DeleteNodeSafe(n *RbMapNode) bool {
+    if n == nil || !t.owns(n) {
+        return false
+    }
+    t.DeleteNode(n)
+    return true
+}
+
+// Returns true if n is reachable from the root of this tree.
+func (t *RbMap) owns(n *RbMapNode) bool {
+    for n.parent != nil {
+        if n != n.parent.left && n != n.parent.right {
+            return false // stale parent link of a detached node
+        }
+        n = n.parent
+    }
+    return n == t.root
 }
 
 func (t* RbMap) rb_delete_fixup(n *RbMapNode) {
@@ -300,6 +714,8 @@ func (t *RbMap) left_rotate(n *RbMapNode) {
         r.left.parent = n
     } 
     r.left, n.parent = n, r
+    r.size = n.size
+    n.size = nodeSize(n.left) + nodeSize(n.right) + 1
 }
 
 func (t *RbMap) right_rotate(n *RbMapNode) {
@@ -310,6 +726,8 @@ func (t *RbMap) right_rotate(n *RbMapNode) {
         l.right.parent = n
     }
     l.right, n.parent = n, l
+    l.size = n.size
+    n.size = nodeSize(n.left) + nodeSize(n.right) + 1
 }
 
 func (t *RbMap) rbreplace(u, v *RbMapNode) {
@@ -326,6 +744,14 @@ func (t *RbMap) rbreplace(u, v *RbMapNode) {
     }
 }
 
+// Returns number of nodes in subtree rooted at n.
+func nodeSize(n *RbMapNode) int {
+    if n == nil {
+        return 0
+    }
+    return n.size
+}
+
 func isBlack(n *RbMapNode) bool {
     return nil == n || !n.isred
 }
@@ -366,6 +792,7 @@ func (t *RbMap) verify() {
     if isRed(t.root) { panic("root is red") }
     verify1(t.root)
     verify2(t.root)
+    if err := t.CheckSizes(); err != nil { panic(err) }
 }
 
 func verify1(n *RbMapNode) {
@@ -1,8 +1,10 @@
 package rbt
 
 import (
+    "errors"
     "testing"
     "math/rand"
+    "sort"
     "time"
 )
 var _,_ = rand.Seed, time.Now
@@ -87,4 +89,450 @@ func TestDelete(t *testing.T) {
         r.DeleteNode(n)
     }
     if r.Size() != 0 { t.Fatalf("tree size non-null after delete") }
-}
\ No newline at end of file
+}
+
+func TestInsertWith(t *testing.T) {
+    r := NewRbMap(func(k1, k2 interface{}) bool {
+        return k1.(string) < k2.(string)
+    })
+    sum := func(old, new interface{}) interface{} { return old.(int) + new.(int) }
+    for _, k := range []string{"a", "b", "a", "c", "a", "b"} {
+        r.InsertWith(k, 1, sum)
+    }
+    if r.Size() != 3 || r.Find("a") != 3 || r.Find("b") != 2 || r.Find("c") != 1 {
+        t.Fatalf("InsertWith: unexpected counts a=%v b=%v c=%v", r.Find("a"), r.Find("b"), r.Find("c"))
+    }
+    if r.InsertWith("a", 1, sum) || !r.InsertWith("d", 5, sum) {
+        t.Fatalf("InsertWith: wrong return value")
+    }
+    r.verify()
+}
+
+func TestFindApprox(t *testing.T) {
+    r := NewRbMap(func(k1, k2 interface{}) bool {
+        return k1.(float64) < k2.(float64)
+    })
+    for _, k := range []float64{1.0, 1.01, 2.0, 3.0} {
+        r.Insert(k, k)
+    }
+    within := func(a, b interface{}) bool {
+        d := a.(float64) - b.(float64)
+        return d < 0.01 && d > -0.01
+    }
+    if n := r.FindApprox(2.0, within); n == nil || n.Key() != 2.0 {
+        t.Fatalf("exact key not found")
+    }
+    if n := r.FindApprox(1.995, within); n == nil || n.Key() != 2.0 {
+        t.Fatalf("ceiling neighbour not found")
+    }
+    if n := r.FindApprox(3.005, within); n == nil || n.Key() != 3.0 {
+        t.Fatalf("floor neighbour not found")
+    }
+    if n := r.FindApprox(1.004, within); n == nil || n.Key() != 1.01 {
+        t.Fatalf("greater neighbour is not preferred")
+    }
+    if n := r.FindApprox(2.5, within); n != nil {
+        t.Fatalf("unexpected match %v", n.Key())
+    }
+}
+
+func TestDeleteNodeSafe(t *testing.T) {
+    a := newtree(t, 1000)
+    b := newtree(t, 1000)
+    size := a.Size()
+    if a.DeleteNodeSafe(b.First()) || a.DeleteNodeSafe(nil) || a.Size() != size {
+        t.Fatalf("foreign node deleted")
+    }
+    n := a.First().Next()
+    k := n.Key()
+    if !a.DeleteNodeSafe(n) || a.Size() != size-1 || a.FindNode(k) != nil {
+        t.Fatalf("own node not deleted")
+    }
+    a.verify()
+    // the last node has no right child, so deletion unlinks it, even though
+    // its parent link remains
+    l := a.Last()
+    a.DeleteNode(l)
+    if a.DeleteNodeSafe(l) || a.Size() != size-2 {
+        t.Fatalf("detached node deleted")
+    }
+}
+
+func TestInsertChecked(t *testing.T) {
+    r := NewRbMap(func(k1, k2 interface{}) bool {
+        return k1.(int) < k2.(int)
+    })
+    if err := r.InsertChecked(nil, 1); err != ErrNilKey || r.Size() != 0 {
+        t.Fatalf("nil key: got %v", err)
+    }
+    if err := r.InsertChecked(1, 1); err != nil || r.Find(1) != 1 {
+        t.Fatalf("valid key: got %v", err)
+    }
+}
+
+func TestSwap(t *testing.T) {
+    a := newtree(t, 100)
+    b := NewRbMap(func(k1, k2 interface{}) bool {
+        return k1.(string) < k2.(string)
+    })
+    b.Insert("x", 1)
+    na := a.Size()
+    a.Swap(b)
+    if a.Size() != 1 || a.Find("x") != 1 || b.Size() != na {
+        t.Fatalf("swap: sizes %d/%d", a.Size(), b.Size())
+    }
+    a.Insert("y", 2)
+    b.Insert(-1, 0)
+    a.verify()
+    b.verify()
+}
+
+func TestInsertIfChanged(t *testing.T) {
+    r := NewRbMap(func(k1, k2 interface{}) bool {
+        return k1.(int) < k2.(int)
+    })
+    eq := func(a, b interface{}) bool { return a == b }
+    if !r.InsertIfChanged(1, "a", eq) {
+        t.Fatalf("new key not reported as change")
+    }
+    if r.InsertIfChanged(1, "a", eq) {
+        t.Fatalf("equal value reported as change")
+    }
+    if !r.InsertIfChanged(1, "b", eq) || r.Find(1) != "b" {
+        t.Fatalf("changed value not stored")
+    }
+}
+
+func TestGetOrLoad(t *testing.T) {
+    r := NewRbMap(func(k1, k2 interface{}) bool {
+        return k1.(int) < k2.(int)
+    })
+    loads := 0
+    load := func(k interface{}) (interface{}, bool) {
+        loads++
+        return k.(int) * 2, k.(int) > 0
+    }
+    if v, ok := r.GetOrLoad(2, load); !ok || v != 4 || r.Find(2) != 4 {
+        t.Fatalf("load on miss: %v, %v", v, ok)
+    }
+    if v, ok := r.GetOrLoad(2, load); !ok || v != 4 || loads != 1 {
+        t.Fatalf("cached value reloaded")
+    }
+    if _, ok := r.GetOrLoad(-1, load); ok || r.Size() != 1 {
+        t.Fatalf("failed load inserted")
+    }
+}
+
+func TestRename(t *testing.T) {
+    r := NewRbMap(func(k1, k2 interface{}) bool {
+        return k1.(string) < k2.(string)
+    })
+    r.Insert("a", 1)
+    r.Insert("b", 2)
+    if ok, ow := r.Rename("a", "c"); !ok || ow || r.Find("c") != 1 || r.FindNode("a") != nil {
+        t.Fatalf("rename to new key: %v, %v", ok, ow)
+    }
+    if ok, ow := r.Rename("c", "b"); !ok || !ow || r.Find("b") != 1 || r.Size() != 1 {
+        t.Fatalf("rename to existing key: %v, %v", ok, ow)
+    }
+    if ok, _ := r.Rename("x", "y"); ok || r.Size() != 1 {
+        t.Fatalf("rename of missing key")
+    }
+    if ok, ow := r.Rename("b", "b"); !ok || ow || r.Find("b") != 1 {
+        t.Fatalf("rename to same key: %v, %v", ok, ow)
+    }
+}
+
+func TestOrderedMap(t *testing.T) {
+    var m OrderedMap = NewRbMap(func(k1, k2 interface{}) bool {
+        return k1.(int) < k2.(int)
+    })
+    m.Insert(2, nil)
+    m.Insert(1, "a")
+    if v, ok := m.Get(2); !ok || v != nil {
+        t.Fatalf("get of nil value: %v, %v", v, ok)
+    }
+    if _, ok := m.Get(3); ok {
+        t.Fatalf("get of missing key")
+    }
+    var keys []int
+    m.Range(func(k, v interface{}) bool {
+        keys = append(keys, k.(int))
+        return true
+    })
+    if m.Len() != 2 || len(keys) != 2 || keys[0] != 1 || keys[1] != 2 {
+        t.Fatalf("range: got %v", keys)
+    }
+    if !m.Delete(1) || m.Len() != 1 {
+        t.Fatalf("delete")
+    }
+}
+
+func TestSpan(t *testing.T) {
+    r := NewRbMap(func(k1, k2 interface{}) bool {
+        return k1.(int) < k2.(int)
+    })
+    if _, _, ok := r.Span(); ok {
+        t.Fatalf("span of empty tree")
+    }
+    r.Insert(5, nil)
+    r.Insert(-3, nil)
+    r.Insert(8, nil)
+    if lo, hi, ok := r.Span(); !ok || lo != -3 || hi != 8 {
+        t.Fatalf("span: %v, %v, %v", lo, hi, ok)
+    }
+}
+
+func TestFindGroup(t *testing.T) {
+    // keys are [2]int pairs; a probe int matches all pairs with that first element
+    first := func(k interface{}) (int, int) {
+        if p, ok := k.([2]int); ok {
+            return p[0], p[1]
+        }
+        return k.(int), -1
+    }
+    r := NewRbMap(func(k1, k2 interface{}) bool {
+        a0, a1 := first(k1)
+        b0, b1 := first(k2)
+        if a0 != b0 {
+            return a0 < b0
+        }
+        if a1 < 0 || b1 < 0 {
+            return false // probe equals every pair of its group
+        }
+        return a1 < b1
+    })
+    for _, k := range [][2]int{{1, 1}, {2, 1}, {2, 2}, {2, 3}, {3, 1}} {
+        r.Insert(k, nil)
+    }
+    g := r.FindGroup(2)
+    if len(g) != 3 || g[0].Key() != [2]int{2, 1} || g[2].Key() != [2]int{2, 3} {
+        t.Fatalf("group: got %d nodes", len(g))
+    }
+    if len(r.FindGroup(5)) != 0 || len(r.FindGroup([2]int{3, 1})) != 1 {
+        t.Fatalf("group of missing or exact key")
+    }
+}
+
+func TestInsertValidated(t *testing.T) {
+    r := NewRbMap(func(k1, k2 interface{}) bool {
+        return k1.(int) < k2.(int)
+    })
+    errNeg := errors.New("negative key")
+    valid := func(k, v interface{}) error {
+        if k.(int) < 0 {
+            return errNeg
+        }
+        return nil
+    }
+    if err := r.InsertValidated(-1, 0, valid); err != errNeg || r.Size() != 0 {
+        t.Fatalf("invalid entry: %v", err)
+    }
+    if err := r.InsertValidated(1, 0, valid); err != nil || r.Size() != 1 {
+        t.Fatalf("valid entry: %v", err)
+    }
+}
+
+func TestAncestors(t *testing.T) {
+    r := newtree(t, 1000)
+    if r.root.Parent() != nil {
+        t.Fatalf("root has parent")
+    }
+    n := r.First()
+    var path []*RbMapNode
+    n.Ancestors(func(p *RbMapNode) bool {
+        path = append(path, p)
+        return true
+    })
+    if len(path) == 0 || path[0] != n.Parent() || path[len(path)-1] != r.root {
+        t.Fatalf("ancestor path does not lead from parent to root")
+    }
+    cnt := 0
+    n.Ancestors(func(p *RbMapNode) bool {
+        cnt++
+        return false
+    })
+    if cnt != 1 {
+        t.Fatalf("early stop: visited %d", cnt)
+    }
+}
+
+func TestIsMinMax(t *testing.T) {
+    r := NewRbMap(func(k1, k2 interface{}) bool {
+        return k1.(int) < k2.(int)
+    })
+    if r.IsMin(1) || r.IsMax(1) {
+        t.Fatalf("extremes of empty tree")
+    }
+    for _, k := range []int{5, 1, 9} {
+        r.Insert(k, nil)
+    }
+    if !r.IsMin(1) || r.IsMin(5) || r.IsMin(0) || !r.IsMax(9) || r.IsMax(5) || r.IsMax(10) {
+        t.Fatalf("wrong extremes")
+    }
+}
+
+func TestInsertWouldRotate(t *testing.T) {
+    r := NewRbMap(func(k1, k2 interface{}) bool {
+        return k1.(int) < k2.(int)
+    })
+    rotations := 0
+    for i := 0; i < 5000; i++ {
+        k := rand.Intn(10000)
+        predicted := r.InsertWouldRotate(k)
+        // search path to the insertion point
+        var path []*RbMapNode
+        for x := r.root; x != nil; {
+            path = append(path, x)
+            if k < x.key.(int) {
+                x = x.left
+            } else {
+                x = x.right
+            }
+        }
+        n, added := r.insertNode(k)
+        if !added {
+            if predicted {
+                t.Fatalf("rotation predicted for existing key %d", k)
+            }
+            continue
+        }
+        // rotation on the search path changes ancestors of the new node
+        var anc []*RbMapNode
+        n.Ancestors(func(p *RbMapNode) bool {
+            anc = append([]*RbMapNode{p}, anc...)
+            return true
+        })
+        rotated := len(anc) != len(path)
+        for j := 0; !rotated && j < len(anc); j++ {
+            rotated = anc[j] != path[j]
+        }
+        if rotated != predicted {
+            t.Fatalf("insert %d: predicted rotation %v, got %v", k, predicted, rotated)
+        }
+        if rotated {
+            rotations++
+        }
+    }
+    if rotations == 0 {
+        t.Fatalf("no rotations seen")
+    }
+}
+
+func TestIncrement(t *testing.T) {
+    r := NewRbMap(func(k1, k2 interface{}) bool {
+        return k1.(string) < k2.(string)
+    })
+    if r.Increment("a", 3) != 3 || r.Increment("a", 2) != 5 || r.Increment("b", -1) != -1 {
+        t.Fatalf("wrong counts")
+    }
+    if r.Size() != 2 || r.Find("a") != 5 || r.Find("b") != -1 {
+        t.Fatalf("wrong contents: a=%v b=%v", r.Find("a"), r.Find("b"))
+    }
+}
+
+func TestBounds(t *testing.T) {
+    r := NewRbMap(func(k1, k2 interface{}) bool {
+        return k1.(int) < k2.(int)
+    })
+    key := func(n *RbMapNode) interface{} {
+        if n == nil {
+            return nil
+        }
+        return n.Key()
+    }
+    if r.LowerBound(1) != nil || r.UpperBound(1) != nil || r.Floor(1) != nil || r.Ceil(1) != nil {
+        t.Fatalf("bounds in empty tree")
+    }
+    for _, k := range []int{10, 20, 30} {
+        r.Insert(k, nil)
+    }
+    for _, tc := range []struct {
+        key                    int
+        lower, upper, fl, ceil interface{}
+    }{
+        {5, 10, 10, nil, 10},
+        {10, 10, 20, 10, 10},
+        {15, 20, 20, 10, 20},
+        {30, 30, nil, 30, 30},
+        {35, nil, nil, 30, nil},
+    } {
+        if key(r.LowerBound(tc.key)) != tc.lower || key(r.UpperBound(tc.key)) != tc.upper ||
+            key(r.Floor(tc.key)) != tc.fl || key(r.Ceil(tc.key)) != tc.ceil {
+            t.Fatalf("bounds of %d: %v %v %v %v", tc.key, key(r.LowerBound(tc.key)),
+                key(r.UpperBound(tc.key)), key(r.Floor(tc.key)), key(r.Ceil(tc.key)))
+        }
+    }
+}
+
+func TestSelectRank(t *testing.T) {
+    r := NewRbMap(func(k1, k2 interface{}) bool {
+        return k1.(int) < k2.(int)
+    })
+    var keys []int // sorted reference
+    for i := 0; i < 20000; i++ {
+        k := rand.Intn(2000)
+        j := sort.SearchInts(keys, k)
+        found := j < len(keys) && keys[j] == k
+        if rand.Intn(3) == 0 {
+            r.Delete(k)
+            if found {
+                keys = append(keys[:j], keys[j+1:]...)
+            }
+        } else {
+            r.Insert(k, nil)
+            if !found {
+                keys = append(keys[:j], append([]int{k}, keys[j:]...)...)
+            }
+        }
+        if i%1000 != 0 {
+            if p := rand.Intn(len(keys) + 1); p < len(keys) && r.Select(p).Key() != keys[p] {
+                t.Fatalf("select %d: got %v, want %d", p, r.Select(p).Key(), keys[p])
+            }
+            continue
+        }
+        if err := r.CheckSizes(); err != nil {
+            t.Fatalf("%v", err)
+        }
+        for p, k := range keys {
+            if n := r.Select(p); n == nil || n.Key() != k {
+                t.Fatalf("select %d: got %v, want %d", p, n, k)
+            }
+            if r.Rank(k) != p || r.Rank(k+1) != sort.SearchInts(keys, k+1) {
+                t.Fatalf("rank of %d: got %d, want %d", k, r.Rank(k), p)
+            }
+        }
+        if r.Select(-1) != nil || r.Select(len(keys)) != nil || r.Rank(-1) != 0 || r.Rank(1<<30) != len(keys) {
+            t.Fatalf("out of range select or rank")
+        }
+    }
+}
+
+func TestClone(t *testing.T) {
+    r := newtree(t, 1000)
+    r.TrackInsertionOrder()
+    c := r.Clone()
+    c.verify()
+    if !c.StructuralEqual(r) || c.CheckParents() != nil || c.CheckSizes() != nil {
+        t.Fatalf("clone differs from original")
+    }
+    first := r.First().Key()
+    c.Delete(first)
+    c.Insert(-1, "new")
+    c.verify()
+    if r.Find(first) == nil || r.Find(-1) != nil || r.Size() != c.Size() {
+        t.Fatalf("original modified through clone")
+    }
+    r.Insert(-2, "orig")
+    if c.Find(-2) != nil {
+        t.Fatalf("clone modified through original")
+    }
+    var last interface{}
+    c.RangeByInsertion(func(k, v interface{}) bool {
+        last = k
+        return true
+    })
+    if last != -1 {
+        t.Fatalf("clone insertion order: last is %v", last)
+    }
+}
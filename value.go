@@ -0,0 +1,26 @@
+package rbt
+
+// Install validator for value updates made with SetValue; nil removes it.
+// Validator receives entry key and the proposed value, and rejects the
+// update by returning an error. Only SetValue consults the validator. All
+// other methods storing values bypass it, including those which overwrite
+// values of existing keys: Insert, InsertChecked, InsertWith,
+// InsertIfChanged, InsertValidated, InsertWindowed, Increment,
+// DecrementAndDelete, MergeWith, Merge, ApplyDelta and RemapValues. Direct
+// writes to RbMapNode.Value bypass it too, so libraries exposing nodes
+// should document SetValue as the only way to change values.
+func (t *RbMap) SetValueValidator(validate func(key, value interface{}) error) {
+    t.validate = validate
+}
+
+// Set value of the node, if it is accepted by the validator installed with
+// SetValueValidator. Returns validator error, leaving the value unchanged.
+func (t *RbMap) SetValue(n *RbMapNode, value interface{}) error {
+    if t.validate != nil {
+        if err := t.validate(n.key, value); err != nil {
+            return err
+        }
+    }
+    n.Value = value
+    return nil
+}
@@ -0,0 +1,30 @@
+package rbt
+
+import (
+    "errors"
+    "testing"
+)
+
+func TestSetValue(t *testing.T) {
+    r := newstrtree("a", "b")
+    n := r.FindNode("a")
+    if err := r.SetValue(n, "x"); err != nil || n.Value != "x" {
+        t.Fatalf("SetValue without validator: %v", err)
+    }
+    errType := errors.New("value must be int")
+    r.SetValueValidator(func(k, v interface{}) error {
+        if _, ok := v.(int); !ok {
+            return errType
+        }
+        return nil
+    })
+    if err := r.SetValue(n, "y"); err != errType || n.Value != "x" {
+        t.Fatalf("invalid value accepted: %v, %v", err, n.Value)
+    }
+    if err := r.SetValue(n, 7); err != nil || r.Find("a") != 7 {
+        t.Fatalf("valid value rejected: %v", err)
+    }
+    if !r.Insert("c", "z") {
+        t.Fatalf("insert affected by validator")
+    }
+}
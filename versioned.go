@@ -0,0 +1,328 @@
+package rbt
+
+// VersionedRbMap is a persistent ordered map: every mutation creates a new
+// numbered version, while all previous versions stay accessible. Versions
+// share unchanged nodes, so a mutation costs O(log n) time and memory.
+// Internally this is a left-leaning red-black tree with path copying, as
+// parent pointers of RbMap nodes do not allow sharing. Version 0 is the
+// empty map. Note: all methods are not goroutine-safe.
+type VersionedRbMap struct {
+    less  LessFunc
+    roots []*pnode
+    sizes []int
+}
+
+// Persistent tree node, never modified once it is part of a version.
+type pnode struct {
+    left, right *pnode
+    key, value  interface{}
+    red         bool
+}
+
+// Create new VersionedRbMap with provided key comparison function.
+func NewVersionedRbMap(less LessFunc) *VersionedRbMap {
+    return &VersionedRbMap{less: less, roots: []*pnode{nil}, sizes: []int{0}}
+}
+
+// Returns the latest version number.
+func (m *VersionedRbMap) Version() uint64 {
+    return uint64(len(m.roots) - 1)
+}
+
+// Returns number of entries in the given version, or -1 if there is no
+// such version.
+func (m *VersionedRbMap) Size(v uint64) int {
+    if v > m.Version() {
+        return -1
+    }
+    return m.sizes[v]
+}
+
+// Find value by key in the given version; ok is false if key or version is
+// not found.
+func (m *VersionedRbMap) Get(v uint64, key interface{}) (value interface{}, ok bool) {
+    if v > m.Version() {
+        return nil, false
+    }
+    if n := m.find(m.roots[v], key); n != nil {
+        return n.value, true
+    }
+    return nil, false
+}
+
+// Insert key and value into the latest version, creating and returning a
+// new version.
+func (m *VersionedRbMap) Insert(key, value interface{}) uint64 {
+    root, added := m.put(m.roots[len(m.roots)-1], key, value)
+    root.red = false
+    size := m.sizes[len(m.sizes)-1]
+    if added {
+        size++
+    }
+    return m.push(root, size)
+}
+
+// Delete key from the latest version. If key is found, a new version is
+// created and returned with ok == true. Otherwise, no version is created,
+// and the latest version is returned with ok == false.
+func (m *VersionedRbMap) Delete(key interface{}) (v uint64, ok bool) {
+    root := m.roots[len(m.roots)-1]
+    if m.find(root, key) == nil {
+        return m.Version(), false
+    }
+    root = root.clone()
+    if !root.left.isRed() && !root.right.isRed() {
+        root.red = true
+    }
+    if root = m.delete(root, key); root != nil {
+        root.red = false
+    }
+    return m.push(root, m.sizes[len(m.sizes)-1]-1), true
+}
+
+// Iterate over entries of the given version in ascending key order, until
+// f returns false.
+func (m *VersionedRbMap) Range(v uint64, f func(key, value interface{}) bool) {
+    if v <= m.Version() {
+        prange(m.roots[v], f)
+    }
+}
+
+// VersionView is a read-only view of one version of VersionedRbMap. It
+// shares nodes with the map, so it takes O(1) time and memory to create,
+// and stays valid as new versions are created.
+type VersionView struct {
+    m    *VersionedRbMap
+    root *pnode
+    size int
+}
+
+// Returns read-only view of the given version, or nil if there is no such
+// version.
+func (m *VersionedRbMap) AtVersion(v uint64) *VersionView {
+    if v > m.Version() {
+        return nil
+    }
+    return &VersionView{m, m.roots[v], m.sizes[v]}
+}
+
+// Returns number of entries in the view.
+func (w *VersionView) Size() int {
+    return w.size
+}
+
+// Find value by key; ok is false if key is not found.
+func (w *VersionView) Get(key interface{}) (value interface{}, ok bool) {
+    if n := w.m.find(w.root, key); n != nil {
+        return n.value, true
+    }
+    return nil, false
+}
+
+// Iterate over entries in ascending key order, until f returns false.
+func (w *VersionView) Range(f func(key, value interface{}) bool) {
+    prange(w.root, f)
+}
+
+// Returns entries of the view as a new RbMap, built in O(n). The returned
+// tree is independent: modifying it does not affect any version.
+func (w *VersionView) Copy() *RbMap {
+    nodes := make([]*RbMapNode, 0, w.size)
+    prange(w.root, func(key, value interface{}) bool {
+        nodes = append(nodes, &RbMapNode{key: key, Value: value})
+        return true
+    })
+    t := NewRbMap(w.m.less)
+    t.build(nodes)
+    return t
+}
+
+// Returns true if versions v1 and v2 hold equal keys with values equal by
+// valueEq. Versions share unchanged subtrees, and shared subtrees are equal
+// without descending into them, so comparing a version with one derived
+// from it by a few mutations costs about O(k log n) for k mutations. If the
+// shapes of the versions diverge, comparison falls back to the walk over all
+// entries, in O(n). Returns false if either version does not exist.
+func (m *VersionedRbMap) EqualFast(v1, v2 uint64, valueEq func(a, b interface{}) bool) bool {
+    if v1 > m.Version() || v2 > m.Version() || m.sizes[v1] != m.sizes[v2] {
+        return false
+    }
+    if m.sharedEqual(m.roots[v1], m.roots[v2], valueEq) {
+        return true
+    }
+    var e []Entry
+    prange(m.roots[v1], func(key, value interface{}) bool {
+        e = append(e, Entry{key, value})
+        return true
+    })
+    i := 0
+    return prange(m.roots[v2], func(key, value interface{}) bool {
+        a := e[i]
+        i++
+        return !m.less(a.Key, key) && !m.less(key, a.Key) && valueEq(a.Value, value)
+    })
+}
+
+// Compares subtrees of the same shape, reports false if they differ in
+// contents or shape.
+func (m *VersionedRbMap) sharedEqual(a, b *pnode, valueEq func(a, b interface{}) bool) bool {
+    if a == b {
+        return true
+    }
+    if a == nil || b == nil {
+        return false
+    }
+    return !m.less(a.key, b.key) && !m.less(b.key, a.key) && valueEq(a.value, b.value) &&
+        m.sharedEqual(a.left, b.left, valueEq) &&
+        m.sharedEqual(a.right, b.right, valueEq)
+}
+
+func (m *VersionedRbMap) push(root *pnode, size int) uint64 {
+    m.roots = append(m.roots, root)
+    m.sizes = append(m.sizes, size)
+    return m.Version()
+}
+
+func (m *VersionedRbMap) find(h *pnode, key interface{}) *pnode {
+    for h != nil {
+        if m.less(key, h.key) {
+            h = h.left
+        } else if m.less(h.key, key) {
+            h = h.right
+        } else {
+            return h
+        }
+    }
+    return nil
+}
+
+func prange(h *pnode, f func(key, value interface{}) bool) bool {
+    if h == nil {
+        return true
+    }
+    return prange(h.left, f) && f(h.key, h.value) && prange(h.right, f)
+}
+
+// All functions below return new subtree root and never modify nodes
+// reachable from existing versions: every node is cloned before change.
+
+func (m *VersionedRbMap) put(h *pnode, key, value interface{}) (*pnode, bool) {
+    if h == nil {
+        return &pnode{key: key, value: value, red: true}, true
+    }
+    h = h.clone()
+    added := false
+    if m.less(key, h.key) {
+        h.left, added = m.put(h.left, key, value)
+    } else if m.less(h.key, key) {
+        h.right, added = m.put(h.right, key, value)
+    } else {
+        h.value = value
+    }
+    return h.balance(), added
+}
+
+// Delete key, which must be present in the subtree of h. h is already
+// cloned by caller.
+func (m *VersionedRbMap) delete(h *pnode, key interface{}) *pnode {
+    if m.less(key, h.key) {
+        if !h.left.isRed() && !h.left.left.isRed() {
+            h = h.moveRedLeft()
+        }
+        h.left = m.delete(h.left.clone(), key)
+    } else {
+        if h.left.isRed() {
+            h = h.rotateRight()
+        }
+        if !m.less(h.key, key) && h.right == nil {
+            return nil
+        }
+        if !h.right.isRed() && !h.right.left.isRed() {
+            h = h.moveRedRight()
+        }
+        if !m.less(h.key, key) {
+            x := h.right
+            for x.left != nil {
+                x = x.left
+            }
+            h.key, h.value = x.key, x.value
+            h.right = deleteMin(h.right.clone())
+        } else {
+            h.right = m.delete(h.right.clone(), key)
+        }
+    }
+    return h.balance()
+}
+
+// Delete the lowest key in subtree of h, which is already cloned by caller.
+func deleteMin(h *pnode) *pnode {
+    if h.left == nil {
+        return nil
+    }
+    if !h.left.isRed() && !h.left.left.isRed() {
+        h = h.moveRedLeft()
+    }
+    h.left = deleteMin(h.left.clone())
+    return h.balance()
+}
+
+func (h *pnode) clone() *pnode {
+    c := *h
+    return &c
+}
+
+func (h *pnode) isRed() bool {
+    return h != nil && h.red
+}
+
+func (h *pnode) rotateLeft() *pnode {
+    x := h.right.clone()
+    h.right, x.left = x.left, h
+    x.red, h.red = h.red, true
+    return x
+}
+
+func (h *pnode) rotateRight() *pnode {
+    x := h.left.clone()
+    h.left, x.right = x.right, h
+    x.red, h.red = h.red, true
+    return x
+}
+
+func (h *pnode) flipColors() {
+    h.red = !h.red
+    h.left, h.right = h.left.clone(), h.right.clone()
+    h.left.red, h.right.red = !h.left.red, !h.right.red
+}
+
+func (h *pnode) moveRedLeft() *pnode {
+    h.flipColors()
+    if h.right.left.isRed() {
+        h.right = h.right.rotateRight()
+        h = h.rotateLeft()
+        h.flipColors()
+    }
+    return h
+}
+
+func (h *pnode) moveRedRight() *pnode {
+    h.flipColors()
+    if h.left.left.isRed() {
+        h = h.rotateRight()
+        h.flipColors()
+    }
+    return h
+}
+
+func (h *pnode) balance() *pnode {
+    if h.right.isRed() && !h.left.isRed() {
+        h = h.rotateLeft()
+    }
+    if h.left.isRed() && h.left.left.isRed() {
+        h = h.rotateRight()
+    }
+    if h.left.isRed() && h.right.isRed() {
+        h.flipColors()
+    }
+    return h
+}
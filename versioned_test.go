@@ -0,0 +1,142 @@
+package rbt
+
+import (
+    "math/rand"
+    "testing"
+)
+
+// Check left-leaning red-black invariants, returns black height.
+func (h *pnode) verify() int {
+    if h == nil {
+        return 0
+    }
+    if h.right.isRed() {
+        panic("right-leaning red link")
+    }
+    if h.red && h.left.isRed() {
+        panic("two red links in a row")
+    }
+    l, r := h.left.verify(), h.right.verify()
+    if l != r {
+        panic("black count")
+    }
+    if !h.red {
+        l++
+    }
+    return l
+}
+
+func TestVersionedRbMap(t *testing.T) {
+    m := NewVersionedRbMap(func(k1, k2 interface{}) bool {
+        return k1.(int) < k2.(int)
+    })
+    var snapshots []map[int]int
+    ref := make(map[int]int)
+    snapshot := func() {
+        s := make(map[int]int, len(ref))
+        for k, v := range ref {
+            s[k] = v
+        }
+        snapshots = append(snapshots, s)
+    }
+    snapshot()
+    for i := 0; i < 5000; i++ {
+        k := rand.Intn(300)
+        if rand.Intn(3) == 0 {
+            _, exists := ref[k]
+            v, ok := m.Delete(k)
+            if ok != exists {
+                t.Fatalf("delete %d: got %v", k, ok)
+            }
+            if !ok {
+                if v != m.Version() {
+                    t.Fatalf("version created by failed delete")
+                }
+                continue
+            }
+            delete(ref, k)
+        } else {
+            m.Insert(k, i)
+            ref[k] = i
+        }
+        snapshot()
+        if m.Version() != uint64(len(snapshots)-1) {
+            t.Fatalf("version %d, want %d", m.Version(), len(snapshots)-1)
+        }
+    }
+    for v, s := range snapshots {
+        m.roots[v].verify()
+        if m.roots[v].isRed() {
+            t.Fatalf("version %d: root is red", v)
+        }
+        if m.Size(uint64(v)) != len(s) {
+            t.Fatalf("version %d: size %d, want %d", v, m.Size(uint64(v)), len(s))
+        }
+        for k, val := range s {
+            if got, ok := m.Get(uint64(v), k); !ok || got != val {
+                t.Fatalf("version %d: key %d is %v, want %d", v, k, got, val)
+            }
+        }
+    }
+    v := m.Version()
+    w := m.AtVersion(v)
+    m.Insert(-1, 0) // view stays at its version
+    prev, cnt := -1, 0
+    w.Range(func(k, val interface{}) bool {
+        if got, ok := w.Get(k); k.(int) <= prev || !ok || got != val {
+            t.Fatalf("view of version %d: key %v out of order or missing", v, k)
+        }
+        prev = k.(int)
+        cnt++
+        return true
+    })
+    if cnt != w.Size() || cnt != len(ref) {
+        t.Fatalf("view visited %d entries, want %d", cnt, len(ref))
+    }
+    if _, ok := w.Get(-1); ok || m.AtVersion(m.Version()+1) != nil || m.Size(m.Version()+1) != -1 {
+        t.Fatalf("view is not at its version or missing version found")
+    }
+    r := w.Copy()
+    r.verify()
+    r.Insert(-2, 0)
+    if r.Size() != len(ref)+1 || w.Size() != len(ref) {
+        t.Fatalf("copy is not independent")
+    }
+}
+
+func TestVersionedEqualFast(t *testing.T) {
+    m := NewVersionedRbMap(func(k1, k2 interface{}) bool {
+        return k1.(int) < k2.(int)
+    })
+    for i := 0; i < 1000; i++ {
+        m.Insert(i, i)
+    }
+    calls := 0
+    eq := func(a, b interface{}) bool {
+        calls++
+        return a == b
+    }
+    v1 := m.Version()
+    v2 := m.Insert(500, 500) // same contents, only the path to 500 is copied
+    if !m.EqualFast(v1, v2, eq) || !m.EqualFast(v1, v1, eq) {
+        t.Fatalf("equal versions reported different")
+    }
+    if calls > 40 {
+        t.Fatalf("shared subtrees compared: %d value comparisons", calls)
+    }
+    v3 := m.Insert(500, -1)
+    if m.EqualFast(v1, v3, eq) || m.EqualFast(1, v1, eq) || m.EqualFast(v1, 1<<40, eq) {
+        t.Fatalf("different versions reported equal")
+    }
+    m.Insert(500, 500)
+    m.Insert(2000, 0)
+    v5, _ := m.Delete(2000) // may differ in shape from v1
+    if !m.EqualFast(v1, v5, eq) {
+        t.Fatalf("equal contents reported different")
+    }
+    m.Delete(999)
+    v7 := m.Insert(1000, 999)
+    if m.EqualFast(v1, v7, eq) {
+        t.Fatalf("different keys not detected")
+    }
+}
@@ -0,0 +1,125 @@
+package rbt
+
+import (
+    "sort"
+)
+
+// SortedView is a read-only snapshot of tree contents as parallel sorted
+// slices of keys and values. Lookups use binary search over the slices,
+// which avoids pointer chasing and is faster for read-heavy scans. The view
+// does not follow later modifications of the tree; call Freeze again to
+// rebuild it.
+type SortedView struct {
+    less   LessFunc
+    keys   []interface{}
+    values []interface{}
+}
+
+// Create a sorted view of tree contents. Costs O(n).
+func (t *RbMap) Freeze() *SortedView {
+    v := &SortedView{
+        less:   t.less,
+        keys:   make([]interface{}, 0, t.size),
+        values: make([]interface{}, 0, t.size),
+    }
+    for n := t.First(); n != nil; n = n.Next() {
+        v.keys = append(v.keys, n.key)
+        v.values = append(v.values, n.Value)
+    }
+    return v
+}
+
+// Returns number of entries in the view.
+func (v *SortedView) Len() int {
+    return len(v.keys)
+}
+
+// Returns key and value of the i-th entry in ascending key order.
+func (v *SortedView) At(i int) (key, value interface{}) {
+    return v.keys[i], v.values[i]
+}
+
+// Returns index of the first entry with key not less than the given key,
+// or Len() if there is no such entry.
+func (v *SortedView) LowerBound(key interface{}) int {
+    return sort.Search(len(v.keys), func(i int) bool {
+        return !v.less(v.keys[i], key)
+    })
+}
+
+// Find value by key, returns nil if key not found.
+func (v *SortedView) Find(key interface{}) interface{} {
+    i := v.LowerBound(key)
+    if i < len(v.keys) && !v.less(key, v.keys[i]) {
+        return v.values[i]
+    }
+    return nil
+}
+
+// Iterate over entries with keys in [lo, hi) in ascending key order, until
+// f returns false.
+func (v *SortedView) RangeBounds(lo, hi interface{}, f func(key, value interface{}) bool) {
+    for i := v.LowerBound(lo); i < len(v.keys) && v.less(v.keys[i], hi); i++ {
+        if !f(v.keys[i], v.values[i]) {
+            return
+        }
+    }
+}
+
+// ReverseView presents the tree in descending key order, without copying:
+// First is the entry with the highest key, Next moves to lower keys, and
+// LowerBound searches with reversed comparison. Unlike SortedView, it
+// reflects all modifications of the tree.
+type ReverseView struct {
+    t *RbMap
+}
+
+// Create descending view of the tree.
+func (t *RbMap) ReverseView() *ReverseView {
+    return &ReverseView{t}
+}
+
+// Returns number of entries in the tree.
+func (v *ReverseView) Size() int {
+    return v.t.size
+}
+
+// Get the first node in descending order (with highest key value).
+func (v *ReverseView) First() *RbMapNode {
+    return v.t.Last()
+}
+
+// Get the last node in descending order (with lowest key value).
+func (v *ReverseView) Last() *RbMapNode {
+    return v.t.First()
+}
+
+// Get node following n in descending order.
+func (v *ReverseView) Next(n *RbMapNode) *RbMapNode {
+    return n.Prev()
+}
+
+// Get node preceding n in descending order.
+func (v *ReverseView) Prev(n *RbMapNode) *RbMapNode {
+    return n.Next()
+}
+
+// Find value by key, returns nil if key not found.
+func (v *ReverseView) Find(key interface{}) interface{} {
+    return v.t.Find(key)
+}
+
+// Find the first node in descending order with key not greater than the
+// given key, returns nil if there is no such node.
+func (v *ReverseView) LowerBound(key interface{}) *RbMapNode {
+    return v.t.Floor(key)
+}
+
+// Iterate over entries in descending key order, until f returns false.
+func (v *ReverseView) Range(f func(key, value interface{}) bool) {
+    for n := v.t.Last(); n != nil; n = n.Prev() {
+        if !f(n.key, n.Value) {
+            return
+        }
+    }
+}
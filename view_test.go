@@ -0,0 +1,64 @@
+package rbt
+
+import (
+    "testing"
+)
+
+func TestSortedView(t *testing.T) {
+    r := newtree(t, 10000)
+    v := r.Freeze()
+    if v.Len() != r.Size() {
+        t.Fatalf("view size %d, tree size %d", v.Len(), r.Size())
+    }
+    i := 0
+    for n := r.First(); n != nil; n = n.Next() {
+        k, val := v.At(i)
+        if k != n.Key() || val != n.Value || v.Find(k) != n.Value {
+            t.Fatalf("entry %d mismatch", i)
+        }
+        i++
+    }
+    lo, hi := r.First().Next().Key(), r.Last().Key()
+    cnt := 0
+    v.RangeBounds(lo, hi, func(k, val interface{}) bool {
+        cnt++
+        return true
+    })
+    if cnt != r.Size()-2 {
+        t.Fatalf("range bounds: visited %d, want %d", cnt, r.Size()-2)
+    }
+    if v.Find(-1) != nil || v.LowerBound(-1) != 0 || v.LowerBound(1<<30) != v.Len() {
+        t.Fatalf("lookup of out-of-range keys")
+    }
+    r.Clear()
+    if v.Len() == 0 {
+        t.Fatalf("view changed after tree modification")
+    }
+}
+
+func TestReverseView(t *testing.T) {
+    r := newstrtree("b", "d", "a", "c")
+    v := r.ReverseView()
+    var got string
+    for n := v.First(); n != nil; n = v.Next(n) {
+        got += n.Key().(string)
+    }
+    if got != "dcba" || v.Size() != 4 || v.Last().Key() != "a" || v.Prev(v.First()) != nil {
+        t.Fatalf("reverse walk: got %q", got)
+    }
+    got = ""
+    v.Range(func(k, val interface{}) bool {
+        got += k.(string)
+        return k != "b"
+    })
+    if got != "dcb" {
+        t.Fatalf("reverse range: got %q", got)
+    }
+    if n := v.LowerBound("bb"); n == nil || n.Key() != "b" || v.LowerBound("0") != nil {
+        t.Fatalf("reverse lower bound: got %v", n)
+    }
+    r.Insert("e", 4) // view follows the tree
+    if v.First().Key() != "e" || v.Find("e") != 4 {
+        t.Fatalf("view does not follow tree")
+    }
+}